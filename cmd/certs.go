@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"github.com/yuyicai/kubei/internal/config"
+	"github.com/yuyicai/kubei/internal/options"
+	"github.com/yuyicai/kubei/internal/phases/cert"
+	"github.com/yuyicai/kubei/internal/phases/dryrun"
+	"github.com/yuyicai/kubei/internal/preflight"
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+// NewCmdCerts returns "kubei certs" command.
+func NewCmdCerts(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Commands related to handling the certificates of a Kubernetes cluster",
+	}
+
+	cmd.AddCommand(newCmdCertsCheckExpiration(out, nil))
+	cmd.AddCommand(newCmdCertsRenew(out, nil))
+	return cmd
+}
+
+func newCmdCertsCheckExpiration(out io.Writer, certsOptions *runOptions) *cobra.Command {
+	if certsOptions == nil {
+		certsOptions = newCertsOptions()
+	}
+
+	cmd := &cobra.Command{
+		Use:   "check-expiration",
+		Short: "Check the expiration of the certificates and the kubeconfig files on every master",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			masters, err := newCertsData(certsOptions)
+			if err != nil {
+				return err
+			}
+
+			if err := preflight.Prepare(masters); err != nil {
+				return err
+			}
+			defer preflight.CloseSSH(masters)
+
+			expirations, err := cert.CheckExpiration(masters)
+			if err != nil {
+				return err
+			}
+			return cert.PrintExpiration(out, expirations)
+		},
+	}
+
+	addCertsConfigFlags(cmd.Flags(), certsOptions.kubei)
+	return cmd
+}
+
+func newCmdCertsRenew(out io.Writer, certsOptions *runOptions) *cobra.Command {
+	if certsOptions == nil {
+		certsOptions = newCertsOptions()
+	}
+
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("renew [%s|NAME]", cert.RenewAll),
+		Short: "Renew the certificates of the masters with the CAs of the cluster",
+		Long: fmt.Sprintf("Renew all the certificates, or the certificate of NAME: %s. ", strings.Join(cert.RenewableCertNames(), ", ")) +
+			"The certificates are re-signed with the CAs of the first master, then the control plane static Pods are restarted one master at a time",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := cert.RenewAll
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if err := cert.ValidateRenewName(name); err != nil {
+				return err
+			}
+
+			masters, err := newCertsData(certsOptions)
+			if err != nil {
+				return err
+			}
+
+			if err := preflight.Prepare(masters); err != nil {
+				return err
+			}
+			defer preflight.CloseSSH(masters)
+
+			if err := cert.RenewCerts(masters, name); err != nil {
+				return err
+			}
+			return dryrun.Output(masters)
+		},
+	}
+
+	addCertsConfigFlags(cmd.Flags(), certsOptions.kubei)
+	options.AddDryRunFlags(cmd.Flags(), &certsOptions.kubei.DryRun, &certsOptions.kubei.DryRunDir)
+	options.AddCertNotAfterTimeFlags(cmd.Flags(), &certsOptions.kubei.CertNotAfterTime)
+	options.AddControlPlaneEndpointFlags(cmd.Flags(), certsOptions.kubeadm)
+	options.AddCertSANsFlags(cmd.Flags(), certsOptions.kubeadm)
+	return cmd
+}
+
+func addCertsConfigFlags(flagSet *flag.FlagSet, k *options.Kubei) {
+	options.AddConfigFileFlags(flagSet, &k.ConfigFile)
+	options.AddClusterFlags(flagSet, &k.Cluster)
+	options.AddPublicUserInfoConfigFlags(flagSet, &k.ClusterNodes.PublicHostInfo)
+	options.AddKubeClusterNodesConfigFlags(flagSet, &k.ClusterNodes)
+	options.AddJumpServerFlags(flagSet, &k.JumpServer)
+	options.AddSSHConfigFlags(flagSet, &k.SSH)
+}
+
+func newCertsOptions() *runOptions {
+	kubeiOptions := options.NewKubei()
+	kubeadmOptions := options.NewKubeadm()
+
+	return &runOptions{
+		kubei:   kubeiOptions,
+		kubeadm: kubeadmOptions,
+	}
+}
+
+// newCertsData loads the cluster, only the masters are returned as the certificates are on the masters.
+func newCertsData(options *runOptions) (*rundata.Cluster, error) {
+	file, err := configFile(options.kubei)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterCfg, err := config.Load(file, func(c *rundata.Cluster) error {
+		options.kubeadm.ApplyTo(c.Kubeadm)
+		return options.kubei.ApplyTo(c.Kubei)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clusterCfg.WithNodes(clusterCfg.ClusterNodes.Masters, nil), nil
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/yuyicai/kubei/cmd/phases"
 	"github.com/yuyicai/kubei/internal/options"
 	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/state"
 )
 
 // runOptions defines all the init options exposed via flags by kubei.
@@ -32,3 +35,15 @@ func (d *runData) KubeadmCfg() *rundata.Kubeadm {
 func (d *runData) Cluster() *rundata.Cluster {
 	return d.cluster
 }
+
+// configFile returns the configuration file to load, the state file of the cluster if --cluster is set.
+func configFile(k *options.Kubei) (string, error) {
+	if k.Cluster == "" {
+		return k.ConfigFile, nil
+	}
+
+	if k.ConfigFile != "" {
+		return "", fmt.Errorf("--%s and --%s can not be set together", options.Config, options.Cluster)
+	}
+	return state.File(k.Cluster)
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"github.com/yuyicai/kubei/internal/config"
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/options"
+	"github.com/yuyicai/kubei/internal/phases/dryrun"
+	"github.com/yuyicai/kubei/internal/phases/kubeadm"
+	resetphases "github.com/yuyicai/kubei/internal/phases/reset"
+	"github.com/yuyicai/kubei/internal/preflight"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/state"
+)
+
+// NewCmdDelete returns "kubei delete" command.
+func NewCmdDelete(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete resources from an existing Kubernetes cluster",
+	}
+
+	cmd.AddCommand(newCmdDeleteNode(out, nil))
+	return cmd
+}
+
+// deleteNodeData is the run data of kubei delete node.
+type deleteNodeData struct {
+	cluster *rundata.Cluster
+	node    *rundata.Node
+	master  bool
+}
+
+// operator returns the master that drains and deletes the node, it is the first master except the deleted node.
+func (d *deleteNodeData) operator() *rundata.Cluster {
+	return d.cluster.WithNodes(d.remainingMasters()[:1], nil)
+}
+
+func (d *deleteNodeData) remainingMasters() []*rundata.Node {
+	return without(d.cluster.ClusterNodes.Masters, d.node)
+}
+
+// connectedCluster returns the part of the cluster that kubei connects to: the operator, the deleted node,
+// and the workers whose local SLB is updated when a master is deleted.
+func (d *deleteNodeData) connectedCluster() *rundata.Cluster {
+	c := d.cluster
+	operator := d.remainingMasters()[0]
+	if !d.master {
+		return c.WithNodes([]*rundata.Node{operator}, []*rundata.Node{d.node})
+	}
+
+	masters := []*rundata.Node{operator, d.node}
+	if c.HA.Type == constants.HATypeLocalSLB {
+		return c.WithNodes(masters, c.ClusterNodes.Workers)
+	}
+	return c.WithNodes(masters, nil)
+}
+
+func newCmdDeleteNode(out io.Writer, deleteOptions *runOptions) *cobra.Command {
+	if deleteOptions == nil {
+		deleteOptions = newDeleteOptions()
+	}
+
+	cmd := &cobra.Command{
+		Use:   "node NAME",
+		Short: "Drain a node, reset it and delete it from the Kubernetes cluster",
+		Long: "Drain a node through the first master, reset it and delete the Node object. " +
+			"The etcd member of a master is removed too, and the local SLB of the workers stops proxying to its apiserver",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := newDeleteNodeData(args[0], deleteOptions)
+			if err != nil {
+				return err
+			}
+
+			connected := data.connectedCluster()
+			if err := preflight.Prepare(connected); err != nil {
+				return err
+			}
+			defer preflight.CloseSSH(connected)
+
+			if err := runDeleteNode(data); err != nil {
+				return err
+			}
+
+			if err := dryrun.Output(connected); err != nil {
+				return err
+			}
+
+			// the state file is only updated for a cluster loaded from it, a --config file is not turned into a state
+			if deleteOptions.kubei.Cluster == "" {
+				return nil
+			}
+			return state.Save(data.cluster)
+		},
+	}
+
+	addDeleteNodeConfigFlags(cmd.Flags(), deleteOptions.kubei)
+	options.AddControlPlaneEndpointFlags(cmd.Flags(), deleteOptions.kubeadm)
+	return cmd
+}
+
+func runDeleteNode(data *deleteNodeData) error {
+	c := data.cluster
+	name := data.node.Name
+	operator := data.operator()
+
+	color.HiBlue("Deleting node %s 🗑️", name)
+	if err := kubeadm.DrainNode(operator, name); err != nil {
+		return err
+	}
+
+	if data.master {
+		if err := kubeadm.RemoveEtcdMember(operator, name); err != nil {
+			return err
+		}
+	}
+
+	if err := resetphases.ResetKubeadm(c.WithNodes(nil, []*rundata.Node{data.node})); err != nil {
+		return err
+	}
+
+	if err := kubeadm.DeleteNode(operator, name); err != nil {
+		return err
+	}
+
+	if !data.master {
+		c.ClusterNodes.Workers = without(c.ClusterNodes.Workers, data.node)
+		return nil
+	}
+
+	c.ClusterNodes.Masters = data.remainingMasters()
+	return kubeadm.UpdateLocalSLB(c)
+}
+
+func addDeleteNodeConfigFlags(flagSet *flag.FlagSet, k *options.Kubei) {
+	options.AddConfigFileFlags(flagSet, &k.ConfigFile)
+	options.AddClusterFlags(flagSet, &k.Cluster)
+	options.AddDryRunFlags(flagSet, &k.DryRun, &k.DryRunDir)
+	options.AddPublicUserInfoConfigFlags(flagSet, &k.ClusterNodes.PublicHostInfo)
+	options.AddKubeClusterNodesConfigFlags(flagSet, &k.ClusterNodes)
+	options.AddJumpServerFlags(flagSet, &k.JumpServer)
+	options.AddSSHConfigFlags(flagSet, &k.SSH)
+}
+
+func newDeleteOptions() *runOptions {
+	kubeiOptions := options.NewKubei()
+	kubeadmOptions := options.NewKubeadm()
+
+	return &runOptions{
+		kubei:   kubeiOptions,
+		kubeadm: kubeadmOptions,
+	}
+}
+
+func newDeleteNodeData(name string, options *runOptions) (*deleteNodeData, error) {
+	file, err := configFile(options.kubei)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterCfg, err := config.Load(file, func(c *rundata.Cluster) error {
+		options.kubeadm.ApplyTo(c.Kubeadm)
+		return options.kubei.ApplyTo(c.Kubei)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &deleteNodeData{cluster: clusterCfg}
+	for _, node := range clusterCfg.ClusterNodes.Masters {
+		if node.Name == name {
+			data.node, data.master = node, true
+		}
+	}
+	for _, node := range clusterCfg.ClusterNodes.Workers {
+		if node.Name == name {
+			data.node = node
+		}
+	}
+
+	if data.node == nil {
+		return nil, fmt.Errorf("node %q is not found in the cluster", name)
+	}
+	if len(data.remainingMasters()) == 0 {
+		return nil, fmt.Errorf("node %q is the last master of the cluster, use \"kubei reset\" to reset the cluster", name)
+	}
+
+	return data, nil
+}
+
+// without returns the nodes except the node.
+func without(nodes []*rundata.Node, node *rundata.Node) []*rundata.Node {
+	var others []*rundata.Node
+	for _, n := range nodes {
+		if n != node {
+			others = append(others, n)
+		}
+	}
+	return others
+}
@@ -8,9 +8,13 @@ import (
 	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
 
 	initphases "github.com/yuyicai/kubei/cmd/phases/init"
+	"github.com/yuyicai/kubei/internal/config"
 	"github.com/yuyicai/kubei/internal/options"
+	certphases "github.com/yuyicai/kubei/internal/phases/cert"
+	"github.com/yuyicai/kubei/internal/phases/dryrun"
 	"github.com/yuyicai/kubei/internal/preflight"
 	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/state"
 )
 
 // NewCmdInit returns "kubei init" command.
@@ -35,11 +39,14 @@ func NewCmdInit(out io.Writer, initOptions *runOptions) *cobra.Command {
 			return preflight.Prepare(cluster)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return initRunner.Run(args)
+			if err := initRunner.Run(args); err != nil {
+				return err
+			}
+			return state.Save(cluster)
 		},
 		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
 			preflight.CloseSSH(cluster)
-			return nil
+			return dryrun.Output(cluster)
 		},
 		Args: cobra.NoArgs,
 	}
@@ -70,12 +77,20 @@ func NewCmdInit(out io.Writer, initOptions *runOptions) *cobra.Command {
 }
 
 func addInitConfigFlags(flagSet *flag.FlagSet, k *options.Kubei) {
+	options.AddConfigFileFlags(flagSet, &k.ConfigFile)
+	options.AddClusterFlags(flagSet, &k.Cluster)
+	options.AddDryRunFlags(flagSet, &k.DryRun, &k.DryRunDir)
+	options.AddKubernetesConfigFlags(flagSet, &k.Kubernetes)
 	options.AddContainerEngineConfigFlags(flagSet, &k.ContainerEngine)
+	options.AddProxyFlags(flagSet, &k.Proxy)
 	options.AddPublicUserInfoConfigFlags(flagSet, &k.ClusterNodes.PublicHostInfo)
 	options.AddKubeClusterNodesConfigFlags(flagSet, &k.ClusterNodes)
 	options.AddJumpServerFlags(flagSet, &k.JumpServer)
+	options.AddSSHConfigFlags(flagSet, &k.SSH)
 	options.AddOfflinePackageFlags(flagSet, &k.OfflineFile)
 	options.AddCertNotAfterTimeFlags(flagSet, &k.CertNotAfterTime)
+	options.AddCADirFlags(flagSet, &k.CADir)
+	options.AddKeyAlgorithmFlags(flagSet, &k.KeyAlgorithm)
 	options.AddNetworkPluginFlags(flagSet, &k.NetworkType)
 }
 
@@ -90,14 +105,25 @@ func newInitOptions() *runOptions {
 }
 
 func newInitData(cmd *cobra.Command, args []string, options *runOptions, out io.Writer) (*runData, error) {
+	file, err := configFile(options.kubei)
+	if err != nil {
+		return nil, err
+	}
 
-	clusterCfg := rundata.NewCluster()
-
-	options.kubei.ApplyTo(clusterCfg.Kubei)
-	options.kubeadm.ApplyTo(clusterCfg.Kubeadm)
+	clusterCfg, err := config.Load(file, func(c *rundata.Cluster) error {
+		options.kubeadm.ApplyTo(c.Kubeadm)
+		return options.kubei.ApplyTo(c.Kubei)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	rundata.DefaultKubeiCfg(clusterCfg.Kubei)
-	rundata.DefaultkubeadmCfg(clusterCfg.Kubeadm, clusterCfg.Kubei)
+	// the CAs are checked before anything runs on the nodes, they are loaded again by the cert phase
+	if clusterCfg.CADir != "" {
+		if _, err := certphases.LoadCAs(clusterCfg.CADir); err != nil {
+			return nil, err
+		}
+	}
 
 	initDatacfg := &runData{
 		cluster: clusterCfg,
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"k8s.io/klog"
+	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
+
+	"github.com/yuyicai/kubei/cmd/phases"
+	joinphases "github.com/yuyicai/kubei/cmd/phases/join"
+	"github.com/yuyicai/kubei/internal/config"
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/options"
+	certphases "github.com/yuyicai/kubei/internal/phases/cert"
+	"github.com/yuyicai/kubei/internal/phases/dryrun"
+	"github.com/yuyicai/kubei/internal/preflight"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/state"
+)
+
+// compile-time assert that the local data object satisfies the phases data interface.
+var _ phases.JoinData = &joinData{}
+
+// joinData is the run data of kubei join, the cluster holds the existing nodes and the nodes joining the cluster.
+type joinData struct {
+	runData
+	joining      []*rundata.Node
+	controlPlane bool
+	cas          rundata.Certificates
+}
+
+func (d *joinData) JoiningNodes() []*rundata.Node {
+	return d.joining
+}
+
+func (d *joinData) ControlPlane() bool {
+	return d.controlPlane
+}
+
+func (d *joinData) CAs() rundata.Certificates {
+	return d.cas
+}
+
+// connectedCluster returns the part of the cluster that kubei connects to: the first master, the joining nodes,
+// and the workers whose local SLB is updated when masters join the cluster.
+func (d *joinData) connectedCluster() *rundata.Cluster {
+	c := d.cluster
+	if !d.controlPlane {
+		return c.WithNodes(c.ClusterNodes.Masters[:1], d.joining)
+	}
+
+	masters := append([]*rundata.Node{c.ClusterNodes.Masters[0]}, d.joining...)
+	if c.HA.Type == constants.HATypeLocalSLB {
+		return c.WithNodes(masters, c.ClusterNodes.Workers)
+	}
+	return c.WithNodes(masters, nil)
+}
+
+// NewCmdJoin returns "kubei join" command.
+func NewCmdJoin(out io.Writer, joinOptions *runOptions) *cobra.Command {
+	if joinOptions == nil {
+		joinOptions = newJoinOptions()
+	}
+	joinRunner := workflow.NewRunner()
+	cluster := &rundata.Cluster{}
+	connected := &rundata.Cluster{}
+
+	cmd := &cobra.Command{
+		Use:   "join",
+		Short: "Run this command in order to join nodes to an existing Kubernetes cluster",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			c, err := joinRunner.InitData(args)
+			if err != nil {
+				return err
+			}
+
+			data := c.(*joinData)
+			cluster = data.Cluster()
+			connected = data.connectedCluster()
+			return preflight.Prepare(connected)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := joinRunner.Run(args); err != nil {
+				return err
+			}
+			return state.Save(cluster)
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			preflight.CloseSSH(connected)
+			return dryrun.Output(connected)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	// adds flags to the join command
+	// join command local flags could be eventually inherited by the sub-commands automatically generated for phases
+	addJoinConfigFlags(cmd.Flags(), joinOptions.kubei)
+	options.AddImageMetaFlags(cmd.Flags(), &joinOptions.kubeadm.ImageRepository)
+	options.AddControlPlaneEndpointFlags(cmd.Flags(), joinOptions.kubeadm)
+	options.AddCertSANsFlags(cmd.Flags(), joinOptions.kubeadm)
+
+	// initialize the workflow runner with the list of phases
+	joinRunner.AppendPhase(joinphases.NewTokenPhase())
+	joinRunner.AppendPhase(joinphases.NewCertPhase())
+	joinRunner.AppendPhase(joinphases.NewSendPhase())
+	joinRunner.AppendPhase(joinphases.NewContainerEnginePhase())
+	joinRunner.AppendPhase(joinphases.NewKubeComponentPhase())
+	joinRunner.AppendPhase(joinphases.NewKubeadmPhase())
+
+	// sets the rundata builder function, that will be used by the runner
+	// both when running the entire workflow or single phases
+	joinRunner.SetDataInitializer(func(cmd *cobra.Command, args []string) (workflow.RunData, error) {
+		return newJoinData(cmd, args, joinOptions, out)
+	})
+
+	// binds the Runner to kubei join command by altering
+	// command help, adding --skip-phases flag and by adding phases subcommands
+	joinRunner.BindToCommand(cmd)
+
+	return cmd
+}
+
+func addJoinConfigFlags(flagSet *flag.FlagSet, k *options.Kubei) {
+	options.AddConfigFileFlags(flagSet, &k.ConfigFile)
+	options.AddClusterFlags(flagSet, &k.Cluster)
+	options.AddDryRunFlags(flagSet, &k.DryRun, &k.DryRunDir)
+	options.AddKubernetesConfigFlags(flagSet, &k.Kubernetes)
+	options.AddContainerEngineConfigFlags(flagSet, &k.ContainerEngine)
+	options.AddProxyFlags(flagSet, &k.Proxy)
+	options.AddPublicUserInfoConfigFlags(flagSet, &k.ClusterNodes.PublicHostInfo)
+	options.AddJoinNodesConfigFlags(flagSet, &k.ClusterNodes)
+	options.AddJoinControlPlaneFlags(flagSet, &k.ControlPlane)
+	options.AddCertNotAfterTimeFlags(flagSet, &k.CertNotAfterTime)
+	options.AddCADirFlags(flagSet, &k.CADir)
+	options.AddKeyAlgorithmFlags(flagSet, &k.KeyAlgorithm)
+	options.AddJumpServerFlags(flagSet, &k.JumpServer)
+	options.AddSSHConfigFlags(flagSet, &k.SSH)
+	options.AddOfflinePackageFlags(flagSet, &k.OfflineFile)
+	options.AddNetworkPluginFlags(flagSet, &k.NetworkType)
+}
+
+func newJoinOptions() *runOptions {
+	kubeiOptions := options.NewKubei()
+	kubeadmOptions := options.NewKubeadm()
+
+	return &runOptions{
+		kubei:   kubeiOptions,
+		kubeadm: kubeadmOptions,
+	}
+}
+
+func newJoinData(cmd *cobra.Command, args []string, options *runOptions, out io.Writer) (*joinData, error) {
+	if len(options.kubei.ClusterNodes.Workers) == 0 {
+		return nil, fmt.Errorf("no node to join, the nodes are set by --nodes")
+	}
+
+	file, err := configFile(options.kubei)
+	if err != nil {
+		return nil, err
+	}
+
+	// the nodes of the configuration file are already in the cluster, only the nodes of --nodes join the cluster
+	var joining, existingWorkers []*rundata.Node
+	clusterCfg, err := config.Load(file, func(c *rundata.Cluster) error {
+		existingWorkers = c.ClusterNodes.Workers
+		c.ClusterNodes.Workers = nil
+		options.kubeadm.ApplyTo(c.Kubeadm)
+		if err := options.kubei.ApplyTo(c.Kubei); err != nil {
+			return err
+		}
+
+		joining = c.ClusterNodes.Workers
+		if len(c.ClusterNodes.Masters) == 0 {
+			return fmt.Errorf("no master of the cluster, the masters are set by --master or loaded by --cluster")
+		}
+
+		if options.kubei.ControlPlane {
+			c.ClusterNodes.Masters = append(c.ClusterNodes.Masters, joining...)
+			c.ClusterNodes.Workers = existingWorkers
+			return nil
+		}
+		c.ClusterNodes.Workers = append(existingWorkers, joining...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &joinData{
+		runData:      runData{cluster: clusterCfg},
+		joining:      joining,
+		controlPlane: options.kubei.ControlPlane,
+	}
+
+	if data.controlPlane && options.kubei.Cluster != "" {
+		if data.cas, clusterCfg.ServiceAccountKey, err = state.LoadPKI(options.kubei.Cluster); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.controlPlane && len(data.cas) == 0 && clusterCfg.CADir != "" {
+		if data.cas, err = certphases.LoadCAs(clusterCfg.CADir); err != nil {
+			return nil, err
+		}
+		if clusterCfg.ServiceAccountKey, err = certphases.LoadServiceAccountKey(clusterCfg.CADir); err != nil {
+			return nil, err
+		}
+		// the CAs that are not in the directory were created by kubei when the cluster was created,
+		// and the masters must share the service account key to validate the tokens signed by each other
+		if len(data.cas) < 3 || len(clusterCfg.ServiceAccountKey.Key) == 0 {
+			klog.V(2).Infof("[cert] Not all the CAs and the service account key of the cluster are in %s, the certificates are uploaded by kubeadm", clusterCfg.CADir)
+			data.cas = nil
+			clusterCfg.ServiceAccountKey = rundata.KeyPair{}
+		}
+	}
+
+	return data, nil
+}
@@ -9,3 +9,14 @@ type RunData interface {
 	KubeadmCfg() *rundata.Kubeadm
 	Cluster() *rundata.Cluster
 }
+
+// JoinData is the run data of kubei join, the cluster holds the existing nodes and the nodes joining the cluster.
+type JoinData interface {
+	RunData
+	// JoiningNodes returns the nodes joining the cluster
+	JoiningNodes() []*rundata.Node
+	// ControlPlane reports whether the nodes join the cluster as masters
+	ControlPlane() bool
+	// CAs returns the CAs of the cluster saved by kubei, it is empty if the certificates are uploaded by kubeadm instead
+	CAs() rundata.Certificates
+}
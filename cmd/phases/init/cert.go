@@ -24,6 +24,10 @@ func NewCertPhase() workflow.Phase {
 
 func getCertPhaseFlags() []string {
 	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
 		options.JumpServer,
 		options.ControlPlaneEndpoint,
 		options.ServiceCidr,
@@ -33,7 +37,17 @@ func getCertPhaseFlags() []string {
 		options.Port,
 		options.User,
 		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
 		options.CertNotAfterTime,
+		options.CADir,
+		options.KeyAlgorithm,
+		options.APIServerCertExtraSANs,
+		options.EtcdServerCertExtraSANs,
 	}
 	return flags
 }
@@ -50,5 +64,8 @@ func runCert(c workflow.RunData) error {
 		return err
 	}
 
-	return certphases.SendCert(cluster)
+	if err := certphases.SendCert(cluster); err != nil {
+		return err
+	}
+	return certphases.SendExternalKubeletConf(cluster)
 }
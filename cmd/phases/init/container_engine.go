@@ -24,15 +24,31 @@ func NewContainerEnginePhase() workflow.Phase {
 
 func getContainerEnginePhaseFlags() []string {
 	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
 		options.OfflineFile,
 		options.JumpServer,
+		options.ContainerEngineType,
 		options.ContainerEngineVersion,
+		options.RegistryMirrors,
+		options.InsecureRegistries,
+		options.HTTPProxy,
+		options.HTTPSProxy,
+		options.NoProxy,
 		options.Masters,
 		options.Workers,
 		options.Password,
 		options.Port,
 		options.User,
 		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
 	}
 	return flags
 }
@@ -24,15 +24,29 @@ func NewKubeComponentPhase() workflow.Phase {
 
 func getKubeComponentPhaseFlags() []string {
 	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
 		options.OfflineFile,
 		options.JumpServer,
 		options.KubernetesVersion,
+		options.HTTPProxy,
+		options.HTTPSProxy,
+		options.NoProxy,
+		options.ProxyMode,
 		options.Masters,
 		options.Workers,
 		options.Password,
 		options.Port,
 		options.User,
 		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
 	}
 	return flags
 }
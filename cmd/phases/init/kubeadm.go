@@ -27,6 +27,10 @@ func NewKubeadmPhase() workflow.Phase {
 
 func getKubeadmPhaseFlags() []string {
 	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
 		options.OfflineFile,
 		options.JumpServer,
 		options.ControlPlaneEndpoint,
@@ -34,12 +38,20 @@ func getKubeadmPhaseFlags() []string {
 		options.PodNetworkCidr,
 		options.NetworkPlugin,
 		options.ServiceCidr,
+		options.ProxyMode,
+		options.ContainerEngineType,
 		options.Masters,
 		options.Workers,
 		options.Password,
 		options.Port,
 		options.User,
 		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
 	}
 	return flags
 }
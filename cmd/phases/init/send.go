@@ -25,6 +25,10 @@ func NewSendPhase() workflow.Phase {
 
 func getSendPhaseFlags() []string {
 	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
 		options.OfflineFile,
 		options.JumpServer,
 		options.Masters,
@@ -33,6 +37,12 @@ func getSendPhaseFlags() []string {
 		options.Port,
 		options.User,
 		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
 	}
 	return flags
 }
@@ -0,0 +1,80 @@
+package join
+
+import (
+	"errors"
+
+	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
+
+	"github.com/yuyicai/kubei/cmd/phases"
+	"github.com/yuyicai/kubei/internal/options"
+	certphases "github.com/yuyicai/kubei/internal/phases/cert"
+	kubeadmphases "github.com/yuyicai/kubei/internal/phases/kubeadm"
+)
+
+// NewCertPhase creates a kubei workflow phase that implements handling of the certificates of the joining masters.
+func NewCertPhase() workflow.Phase {
+	phase := workflow.Phase{
+		Name:  "cert",
+		Short: "create the certificates of the joining masters",
+		Long: "create the certificates of the joining masters with the CAs saved by kubei, " +
+			"or upload the certificates of the first master if the CAs are not saved by kubei. Only for --control-plane",
+		InheritFlags: getCertPhaseFlags(),
+		Run:          runCert,
+	}
+	return phase
+}
+
+func getCertPhaseFlags() []string {
+	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
+		options.JumpServer,
+		options.ControlPlaneEndpoint,
+		options.Master,
+		options.Workers,
+		options.ControlPlane,
+		options.Password,
+		options.Port,
+		options.User,
+		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
+		options.CertNotAfterTime,
+		options.CADir,
+		options.KeyAlgorithm,
+		options.APIServerCertExtraSANs,
+		options.EtcdServerCertExtraSANs,
+	}
+	return flags
+}
+
+func runCert(c workflow.RunData) error {
+	data, ok := c.(phases.JoinData)
+	if !ok {
+		return errors.New("cert phase invoked with an invalid rundata struct")
+	}
+
+	if !data.ControlPlane() {
+		return nil
+	}
+
+	cluster := data.Cluster()
+
+	// the certificate key of kubeadm expires after two hours, the CAs saved by kubei are preferred
+	if len(data.CAs()) == 0 {
+		return kubeadmphases.UploadCerts(cluster)
+	}
+
+	masters := joiningCluster(data)
+	if err := certphases.CreateCertWithCAs(masters, data.CAs()); err != nil {
+		return err
+	}
+
+	return certphases.SendCert(masters)
+}
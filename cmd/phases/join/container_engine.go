@@ -0,0 +1,66 @@
+package join
+
+import (
+	"errors"
+
+	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
+
+	"github.com/yuyicai/kubei/cmd/phases"
+	"github.com/yuyicai/kubei/internal/options"
+	containerphases "github.com/yuyicai/kubei/internal/phases/container"
+)
+
+// NewContainerEnginePhase creates a kubei workflow phase that implements handling of container engine.
+func NewContainerEnginePhase() workflow.Phase {
+	phase := workflow.Phase{
+		Name:         "container-engine",
+		Short:        "install container engine on the joining nodes",
+		Long:         "install container engine on the joining nodes",
+		InheritFlags: getContainerEnginePhaseFlags(),
+		Run:          runContainerEngine,
+	}
+	return phase
+}
+
+func getContainerEnginePhaseFlags() []string {
+	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
+		options.JumpServer,
+		options.OfflineFile,
+		options.ContainerEngineType,
+		options.ContainerEngineVersion,
+		options.RegistryMirrors,
+		options.InsecureRegistries,
+		options.HTTPProxy,
+		options.HTTPSProxy,
+		options.NoProxy,
+		options.Master,
+		options.Workers,
+		options.ControlPlane,
+		options.Password,
+		options.Port,
+		options.User,
+		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
+	}
+	return flags
+}
+
+func runContainerEngine(c workflow.RunData) error {
+	data, ok := c.(phases.JoinData)
+	if !ok {
+		return errors.New("runtime phase invoked with an invalid data struct")
+	}
+
+	cluster := joiningCluster(data)
+
+	return containerphases.InstallContainerEngine(cluster)
+}
@@ -0,0 +1,15 @@
+package join
+
+import (
+	"github.com/yuyicai/kubei/cmd/phases"
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+// joiningCluster returns the cluster with only the nodes joining the cluster, as masters or as workers.
+func joiningCluster(data phases.JoinData) *rundata.Cluster {
+	c := data.Cluster()
+	if data.ControlPlane() {
+		return c.WithNodes(data.JoiningNodes(), nil)
+	}
+	return c.WithNodes(nil, data.JoiningNodes())
+}
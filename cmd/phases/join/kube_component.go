@@ -0,0 +1,63 @@
+package join
+
+import (
+	"errors"
+
+	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
+
+	"github.com/yuyicai/kubei/cmd/phases"
+	"github.com/yuyicai/kubei/internal/options"
+	kubephases "github.com/yuyicai/kubei/internal/phases/kube"
+)
+
+// NewKubeComponentPhase creates a kubei workflow phase that implements handling of kube.
+func NewKubeComponentPhase() workflow.Phase {
+	phase := workflow.Phase{
+		Name:         "kube",
+		Short:        "install Kubernetes component on the joining nodes",
+		Long:         "install Kubernetes component on the joining nodes, the version is the version of the cluster if --kubernetes-version is not set",
+		InheritFlags: getKubeComponentPhaseFlags(),
+		Run:          runKubeComponent,
+	}
+	return phase
+}
+
+func getKubeComponentPhaseFlags() []string {
+	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
+		options.JumpServer,
+		options.OfflineFile,
+		options.KubernetesVersion,
+		options.HTTPProxy,
+		options.HTTPSProxy,
+		options.NoProxy,
+		options.Master,
+		options.Workers,
+		options.ControlPlane,
+		options.Password,
+		options.Port,
+		options.User,
+		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
+	}
+	return flags
+}
+
+func runKubeComponent(c workflow.RunData) error {
+	data, ok := c.(phases.JoinData)
+	if !ok {
+		return errors.New("kube phase invoked with an invalid data struct")
+	}
+
+	cluster := joiningCluster(data)
+
+	return kubephases.InstallKubeComponent(cluster)
+}
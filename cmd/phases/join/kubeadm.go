@@ -0,0 +1,83 @@
+package join
+
+import (
+	"errors"
+
+	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
+
+	"github.com/yuyicai/kubei/cmd/phases"
+	"github.com/yuyicai/kubei/internal/options"
+	kubeadmphases "github.com/yuyicai/kubei/internal/phases/kubeadm"
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+// NewKubeadmPhase creates a kubei workflow phase that implements handling of kubeadm join.
+func NewKubeadmPhase() workflow.Phase {
+	phase := workflow.Phase{
+		Name:         "kubeadm",
+		Short:        "join the nodes to the cluster with kubeadm",
+		Long:         "join the nodes to the cluster with kubeadm, the bootstrap token is created by the token phase. The local SLB of the workers is updated when masters join the cluster",
+		InheritFlags: getKubeadmPhaseFlags(),
+		Run:          runKubeadm,
+	}
+	return phase
+}
+
+func getKubeadmPhaseFlags() []string {
+	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
+		options.JumpServer,
+		options.OfflineFile,
+		options.ControlPlaneEndpoint,
+		options.ImageRepository,
+		options.NetworkPlugin,
+		options.Master,
+		options.Workers,
+		options.ControlPlane,
+		options.Password,
+		options.Port,
+		options.User,
+		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
+	}
+	return flags
+}
+
+func runKubeadm(c workflow.RunData) error {
+	data, ok := c.(phases.JoinData)
+	if !ok {
+		return errors.New("kubeadm phase invoked with an invalid rundata struct")
+	}
+
+	cluster := data.Cluster()
+
+	if err := kubeadmphases.LoadOfflineImages(joiningCluster(data)); err != nil {
+		return err
+	}
+
+	if data.ControlPlane() {
+		// the new masters join through the first master
+		masters := append([]*rundata.Node{cluster.ClusterNodes.Masters[0]}, data.JoiningNodes()...)
+		if err := kubeadmphases.JoinControlPlane(cluster.WithNodes(masters, nil)); err != nil {
+			return err
+		}
+
+		if err := kubeadmphases.UpdateLocalSLB(cluster); err != nil {
+			return err
+		}
+	} else {
+		if err := kubeadmphases.JoinNode(cluster.WithNodes(cluster.ClusterNodes.Masters, data.JoiningNodes())); err != nil {
+			return err
+		}
+	}
+
+	return kubeadmphases.CheckNodesReady(cluster.WithNodes(cluster.ClusterNodes.Masters[:1], data.JoiningNodes()))
+}
@@ -0,0 +1,61 @@
+package join
+
+import (
+	"errors"
+
+	"github.com/fatih/color"
+	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
+
+	"github.com/yuyicai/kubei/cmd/phases"
+	"github.com/yuyicai/kubei/internal/options"
+	sendphases "github.com/yuyicai/kubei/internal/phases/send"
+)
+
+// NewSendPhase creates a kubei workflow phase that implements handling of send.
+func NewSendPhase() workflow.Phase {
+	phase := workflow.Phase{
+		Name:         "send",
+		Short:        "send kubernetes offline pkg to the joining nodes",
+		Long:         "send kubernetes offline pkg to the joining nodes",
+		InheritFlags: getSendPhaseFlags(),
+		Run:          runSend,
+	}
+	return phase
+}
+
+func getSendPhaseFlags() []string {
+	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
+		options.JumpServer,
+		options.OfflineFile,
+		options.Master,
+		options.Workers,
+		options.ControlPlane,
+		options.Password,
+		options.Port,
+		options.User,
+		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
+	}
+	return flags
+}
+
+func runSend(c workflow.RunData) error {
+	data, ok := c.(phases.JoinData)
+	if !ok {
+		return errors.New("runtime phase invoked with an invalid data struct")
+	}
+
+	cluster := joiningCluster(data)
+
+	color.HiBlue("Sending Kubernetes offline pkg to nodes ✉️")
+	return sendphases.Send(cluster)
+}
@@ -0,0 +1,59 @@
+package join
+
+import (
+	"errors"
+
+	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
+
+	"github.com/yuyicai/kubei/cmd/phases"
+	"github.com/yuyicai/kubei/internal/options"
+	kubeadmphases "github.com/yuyicai/kubei/internal/phases/kubeadm"
+)
+
+// NewTokenPhase creates a kubei workflow phase that implements handling of the bootstrap token.
+func NewTokenPhase() workflow.Phase {
+	phase := workflow.Phase{
+		Name:         "token",
+		Short:        "create bootstrap token on the first master",
+		Long:         "create bootstrap token and get the CA cert hash on the first master",
+		InheritFlags: getTokenPhaseFlags(),
+		Run:          runToken,
+	}
+	return phase
+}
+
+func getTokenPhaseFlags() []string {
+	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
+		options.JumpServer,
+		options.KubernetesVersion,
+		options.Master,
+		options.Workers,
+		options.ControlPlane,
+		options.Password,
+		options.Port,
+		options.User,
+		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
+	}
+	return flags
+}
+
+func runToken(c workflow.RunData) error {
+	data, ok := c.(phases.JoinData)
+	if !ok {
+		return errors.New("token phase invoked with an invalid rundata struct")
+	}
+
+	cluster := data.Cluster()
+
+	return kubeadmphases.CreateToken(cluster)
+}
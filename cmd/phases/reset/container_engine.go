@@ -22,6 +22,10 @@ func NewContainerEnginePhase() workflow.Phase {
 
 func getContainerEnginePhaseFlags() []string {
 	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
 		options.RemoveContainerEngine,
 		options.JumpServer,
 		options.Masters,
@@ -30,6 +34,12 @@ func getContainerEnginePhaseFlags() []string {
 		options.Port,
 		options.User,
 		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
 	}
 	return flags
 }
@@ -22,6 +22,10 @@ func NewKubeComponentPhase() workflow.Phase {
 
 func getKubeComponentPhaseFlags() []string {
 	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
 		options.RemoveKubernetesComponent,
 		options.JumpServer,
 		options.Masters,
@@ -30,6 +34,12 @@ func getKubeComponentPhaseFlags() []string {
 		options.Port,
 		options.User,
 		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
 	}
 	return flags
 }
@@ -22,6 +22,10 @@ func NewKubeadmPhase() workflow.Phase {
 
 func getKubeadmPhaseFlags() []string {
 	flags := []string{
+		options.Config,
+		options.Cluster,
+		options.DryRun,
+		options.DryRunDir,
 		options.JumpServer,
 		options.Masters,
 		options.Workers,
@@ -29,6 +33,12 @@ func getKubeadmPhaseFlags() []string {
 		options.Port,
 		options.User,
 		options.Key,
+		options.KeyPassphrase,
+		options.AuthMethods,
+		options.HostKeyPolicy,
+		options.KnownHosts,
+		options.SSHConfig,
+		options.ProxyJump,
 	}
 	return flags
 }
@@ -9,7 +9,9 @@ import (
 	"k8s.io/kubernetes/cmd/kubeadm/app/cmd/phases/workflow"
 
 	phases "github.com/yuyicai/kubei/cmd/phases/reset"
+	"github.com/yuyicai/kubei/internal/config"
 	"github.com/yuyicai/kubei/internal/options"
+	"github.com/yuyicai/kubei/internal/phases/dryrun"
 	"github.com/yuyicai/kubei/internal/rundata"
 )
 
@@ -39,7 +41,7 @@ func NewCmdReset(out io.Writer, runOptions *runOptions) *cobra.Command {
 		},
 		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
 			preflight.CloseSSH(cluster)
-			return nil
+			return dryrun.Output(cluster)
 		},
 		Args: cobra.NoArgs,
 	}
@@ -68,9 +70,13 @@ func NewCmdReset(out io.Writer, runOptions *runOptions) *cobra.Command {
 }
 
 func addResetConfigFlags(flagSet *flag.FlagSet, k *options.Kubei) {
+	options.AddConfigFileFlags(flagSet, &k.ConfigFile)
+	options.AddClusterFlags(flagSet, &k.Cluster)
+	options.AddDryRunFlags(flagSet, &k.DryRun, &k.DryRunDir)
 	options.AddPublicUserInfoConfigFlags(flagSet, &k.ClusterNodes.PublicHostInfo)
 	options.AddKubeClusterNodesConfigFlags(flagSet, &k.ClusterNodes)
 	options.AddJumpServerFlags(flagSet, &k.JumpServer)
+	options.AddSSHConfigFlags(flagSet, &k.SSH)
 	options.AddResetFlags(flagSet, &k.Reset)
 }
 
@@ -85,13 +91,18 @@ func newResetOptions() *runOptions {
 }
 
 func newResetData(cmd *cobra.Command, args []string, options *runOptions, out io.Writer) (*runData, error) {
-	clusterCfg := rundata.NewCluster()
-
-	options.kubei.ApplyTo(clusterCfg.Kubei)
-	options.kubeadm.ApplyTo(clusterCfg.Kubeadm)
+	file, err := configFile(options.kubei)
+	if err != nil {
+		return nil, err
+	}
 
-	rundata.DefaultKubeiCfg(clusterCfg.Kubei)
-	rundata.DefaultkubeadmCfg(clusterCfg.Kubeadm, clusterCfg.Kubei)
+	clusterCfg, err := config.Load(file, func(c *rundata.Cluster) error {
+		options.kubeadm.ApplyTo(c.Kubeadm)
+		return options.kubei.ApplyTo(c.Kubei)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	initDatacfg := &runData{
 		cluster: clusterCfg,
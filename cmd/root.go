@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/fatih/color"
@@ -11,6 +12,8 @@ import (
 	"github.com/spf13/pflag"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog"
+
+	"github.com/yuyicai/kubei/pkg/redact"
 )
 
 func NewKubeiCommand(in io.Reader, out, err io.Writer) *cobra.Command {
@@ -24,7 +27,11 @@ func NewKubeiCommand(in io.Reader, out, err io.Writer) *cobra.Command {
 	}
 
 	cmds.AddCommand(NewCmdInit(out, nil))
+	cmds.AddCommand(NewCmdJoin(out, nil))
 	cmds.AddCommand(NewCmdReset(out, nil))
+	cmds.AddCommand(NewCmdDelete(out))
+	cmds.AddCommand(NewCmdUpgrade(out, nil))
+	cmds.AddCommand(NewCmdCerts(out))
 	cmds.AddCommand(NewCmdVersion(out))
 	return cmds
 
@@ -37,7 +44,15 @@ func Execute() {
 	pflag.CommandLine.SetNormalizeFunc(cliflag.WordSepNormalizeFunc)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
-	pflag.Set("logtostderr", "true")
+	// the logs are written to stderr through the redact writer, so the secrets are masked in every line.
+	// Every line is written to the INFO output, the outputs of the other severities are discarded to not write it twice
+	pflag.Set("logtostderr", "false")
+	// 4 is above FATAL, no line is written to stderr directly
+	pflag.Set("stderrthreshold", "4")
+	klog.SetOutputBySeverity("INFO", redact.NewWriter(os.Stderr))
+	for _, severity := range []string{"WARNING", "ERROR", "FATAL"} {
+		klog.SetOutputBySeverity(severity, ioutil.Discard)
+	}
 	// We do not want these flags to show up in --help
 	// These MarkHidden calls must be after the lines above
 	pflag.CommandLine.MarkHidden("version")
@@ -57,7 +72,7 @@ func Execute() {
 	cmd := NewKubeiCommand(os.Stdin, os.Stdout, os.Stderr)
 
 	if err := cmd.Execute(); err != nil {
-		fmt.Printf("%s: %v\n", color.RedString("Error"), err)
+		fmt.Printf("%s: %v\n", color.RedString("Error"), redact.Error(err))
 		os.Exit(1)
 	}
 }
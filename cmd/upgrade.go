@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"github.com/yuyicai/kubei/internal/config"
+	"github.com/yuyicai/kubei/internal/options"
+	"github.com/yuyicai/kubei/internal/phases/dryrun"
+	"github.com/yuyicai/kubei/internal/phases/send"
+	"github.com/yuyicai/kubei/internal/phases/upgrade"
+	"github.com/yuyicai/kubei/internal/preflight"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/state"
+)
+
+// NewCmdUpgrade returns "kubei upgrade" command.
+func NewCmdUpgrade(out io.Writer, upgradeOptions *runOptions) *cobra.Command {
+	if upgradeOptions == nil {
+		upgradeOptions = newUpgradeOptions()
+	}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade a Kubernetes cluster to the version of --kubernetes-version",
+		Long: "Upgrade the first master with kubeadm upgrade apply, then the other masters one by one, then the workers in batches. " +
+			"Each node is drained before it is upgraded and uncordoned after, the upgrade stops at the first node that fails",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := newUpgradeData(upgradeOptions)
+			if err != nil {
+				return err
+			}
+
+			if err := preflight.Prepare(cluster); err != nil {
+				return err
+			}
+			defer preflight.CloseSSH(cluster)
+
+			if err := runUpgrade(cluster); err != nil {
+				return err
+			}
+
+			if err := dryrun.Output(cluster); err != nil {
+				return err
+			}
+
+			// the state file is only updated for a cluster loaded from it, a --config file is not turned into a state
+			if upgradeOptions.kubei.Cluster == "" {
+				return nil
+			}
+			return state.Save(cluster)
+		},
+	}
+
+	addUpgradeConfigFlags(cmd.Flags(), upgradeOptions.kubei)
+	options.AddControlPlaneEndpointFlags(cmd.Flags(), upgradeOptions.kubeadm)
+	return cmd
+}
+
+func runUpgrade(c *rundata.Cluster) error {
+	if err := upgrade.CheckVersionSkew(c); err != nil {
+		return err
+	}
+
+	if err := send.Send(c); err != nil {
+		return err
+	}
+
+	if err := upgrade.UpgradeFirstMaster(c); err != nil {
+		return err
+	}
+
+	if err := upgrade.UpgradeOtherMasters(c); err != nil {
+		return err
+	}
+
+	return upgrade.UpgradeWorkers(c)
+}
+
+func addUpgradeConfigFlags(flagSet *flag.FlagSet, k *options.Kubei) {
+	options.AddConfigFileFlags(flagSet, &k.ConfigFile)
+	options.AddClusterFlags(flagSet, &k.Cluster)
+	options.AddDryRunFlags(flagSet, &k.DryRun, &k.DryRunDir)
+	options.AddKubernetesConfigFlags(flagSet, &k.Kubernetes)
+	options.AddProxyFlags(flagSet, &k.Proxy)
+	options.AddPublicUserInfoConfigFlags(flagSet, &k.ClusterNodes.PublicHostInfo)
+	options.AddKubeClusterNodesConfigFlags(flagSet, &k.ClusterNodes)
+	options.AddJumpServerFlags(flagSet, &k.JumpServer)
+	options.AddSSHConfigFlags(flagSet, &k.SSH)
+	options.AddOfflinePackageFlags(flagSet, &k.OfflineFile)
+	options.AddUpgradeFlags(flagSet, &k.Upgrade)
+}
+
+func newUpgradeOptions() *runOptions {
+	kubeiOptions := options.NewKubei()
+	kubeadmOptions := options.NewKubeadm()
+
+	return &runOptions{
+		kubei:   kubeiOptions,
+		kubeadm: kubeadmOptions,
+	}
+}
+
+func newUpgradeData(options *runOptions) (*rundata.Cluster, error) {
+	if options.kubei.Kubernetes.Version == "" {
+		return nil, fmt.Errorf("the version to upgrade to is set by --kubernetes-version")
+	}
+
+	file, err := configFile(options.kubei)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Load(file, func(c *rundata.Cluster) error {
+		options.kubeadm.ApplyTo(c.Kubeadm)
+		return options.kubei.ApplyTo(c.Kubei)
+	})
+}
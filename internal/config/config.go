@@ -0,0 +1,84 @@
+// Package config builds the run data of a cluster from the kubei configuration file and the command line.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/yuyicai/kubei/internal/config/v1alpha1"
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+// Override changes the cluster decoded from the configuration file, e.g. with the flags of the command line.
+type Override func(c *rundata.Cluster) error
+
+// Load builds the cluster: the configuration file is decoded if it is set, then the overrides are applied,
+// then the defaults are set and the result is validated.
+func Load(file string, overrides ...Override) (*rundata.Cluster, error) {
+	c := rundata.NewCluster()
+
+	if file != "" {
+		cfg, err := LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		Convert(cfg, c)
+	}
+
+	for _, override := range overrides {
+		if err := override(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := SetDefaults(c); err != nil {
+		return nil, err
+	}
+
+	if err := Validate(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadFile reads and decodes the configuration file.
+func LoadFile(file string) (*v1alpha1.Cluster, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %s: %v", file, err)
+	}
+
+	cfg, err := Decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode config file %s: %v", file, err)
+	}
+	return cfg, nil
+}
+
+// Encode encodes the configuration to YAML.
+func Encode(cfg *v1alpha1.Cluster) ([]byte, error) {
+	return yaml.Marshal(cfg)
+}
+
+// Decode decodes a configuration in YAML or JSON, the unknown fields are rejected.
+func Decode(b []byte) (*v1alpha1.Cluster, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(b, &typeMeta); err != nil {
+		return nil, err
+	}
+
+	if typeMeta.APIVersion != v1alpha1.APIVersion || typeMeta.Kind != v1alpha1.Kind {
+		return nil, fmt.Errorf("unsupported apiVersion %q and kind %q, supported: apiVersion %q and kind %q",
+			typeMeta.APIVersion, typeMeta.Kind, v1alpha1.APIVersion, v1alpha1.Kind)
+	}
+
+	cfg := &v1alpha1.Cluster{}
+	if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
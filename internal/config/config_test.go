@@ -0,0 +1,390 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuyicai/kubei/internal/config/v1alpha1"
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+// baseConfig is a valid configuration, the ssh_config file does not exist so the nodes are not resolved with it
+const baseConfig = `
+apiVersion: kubei.io/v1alpha1
+kind: Cluster
+nodes:
+  masters:
+  - host: 10.3.0.10
+  workers:
+  - host: 10.3.0.20
+ssh:
+  configFile: /nonexistent/kubei/ssh_config
+`
+
+func loadConfig(t *testing.T, config string) *rundata.Cluster {
+	cfg, err := Decode([]byte(config))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	c := rundata.NewCluster()
+	Convert(cfg, c)
+	if err := SetDefaults(c); err != nil {
+		t.Fatalf("SetDefaults() error = %v", err)
+	}
+	return c
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:   "valid config",
+			config: baseConfig,
+		},
+		{
+			name:    "unknown apiVersion",
+			config:  strings.Replace(baseConfig, "kubei.io/v1alpha1", "kubei.io/v1beta1", 1),
+			wantErr: `unsupported apiVersion "kubei.io/v1beta1" and kind "Cluster"`,
+		},
+		{
+			name:    "unknown kind",
+			config:  strings.Replace(baseConfig, "kind: Cluster", "kind: InitConfiguration", 1),
+			wantErr: `unsupported apiVersion "kubei.io/v1alpha1" and kind "InitConfiguration"`,
+		},
+		{
+			name:    "unknown field",
+			config:  baseConfig + "clusterNmae: test\n",
+			wantErr: `unknown field "clusterNmae"`,
+		},
+		{
+			name:    "unknown nested field",
+			config:  baseConfig + "ha:\n  tpye: local\n",
+			wantErr: `unknown field "tpye"`,
+		},
+		{
+			name:    "unknown field of the addons",
+			config:  baseConfig + "addons:\n  dashboard: true\n",
+			wantErr: `unknown field "dashboard"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Decode([]byte(tt.config))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if len(cfg.Nodes.Masters) != 1 || cfg.Nodes.Masters[0].Host != "10.3.0.10" {
+					t.Errorf("Decode() masters = %v, want the master 10.3.0.10", cfg.Nodes.Masters)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		got    func(c *rundata.Cluster) interface{}
+		want   interface{}
+	}{
+		{
+			name:   "node name, user and port",
+			config: baseConfig,
+			got: func(c *rundata.Cluster) interface{} {
+				n := c.ClusterNodes.Workers[0]
+				return []string{n.Name, n.HostInfo.User, n.HostInfo.Port, n.InstallType}
+			},
+			want: []string{"10.3.0.20", constants.DefaultSSHUser, constants.DefaultSSHPort, constants.InstallTypeOnline},
+		},
+		{
+			name:   "ssh section of the nodes",
+			config: baseConfig + "  user: centos\n  port: 2222\n",
+			got: func(c *rundata.Cluster) interface{} {
+				n := c.ClusterNodes.Masters[0]
+				return []string{n.HostInfo.User, n.HostInfo.Port}
+			},
+			want: []string{"centos", "2222"},
+		},
+		{
+			name:   "offline install",
+			config: baseConfig + "offlineFile: ./kube.tgz\n",
+			got:    func(c *rundata.Cluster) interface{} { return c.ClusterNodes.Masters[0].InstallType },
+			want:   constants.InstallTypeOffline,
+		},
+		{
+			name:   "host key policy",
+			config: baseConfig,
+			got:    func(c *rundata.Cluster) interface{} { return c.SSH.HostKeyPolicy },
+			want:   constants.DefaultHostKeyPolicy,
+		},
+		{
+			name:   "kubeadm",
+			config: baseConfig,
+			got: func(c *rundata.Cluster) interface{} {
+				k := c.Kubeadm
+				return []string{k.ClusterName, k.ControlPlaneEndpoint, k.ImageRepository, k.Networking.PodSubnet,
+					k.Networking.ServiceSubnet, k.Networking.DNSDomain, k.ProxyMode, k.LocalAPIEndpoint.AdvertiseAddress}
+			},
+			want: []string{constants.DefaultClusterName, constants.DefaultControlPlaneEndpoint, constants.DefaultImageRepository,
+				constants.DefaultPodNetworkCidr, constants.DefaultServiceSubnet, "cluster.local", constants.DefaultProxyMode, "10.3.0.10"},
+		},
+		{
+			name:   "container engine",
+			config: baseConfig,
+			got: func(c *rundata.Cluster) interface{} {
+				e := c.ContainerEngine
+				return []string{e.Type, e.Docker.CGroupDriver, e.Docker.LogDriver, e.Docker.LogOptsMaxSize, e.Docker.StorageDriver}
+			},
+			want: []string{constants.ContainerEngineTypeDocker, constants.DefaultCGroupDriver, constants.DefaultLogDriver,
+				constants.DefaultLogOptsMaxSize, constants.DockerDefaultStorageDriver},
+		},
+		{
+			name:   "mirrors are not set",
+			config: baseConfig,
+			got:    func(c *rundata.Cluster) interface{} { return c.ContainerEngine.Registry.DockerIOMirrors() },
+			want:   strings.Split(constants.DefaultRegistryMirrors, ","),
+		},
+		{
+			name: "CRI-O version of the Kubernetes version",
+			config: baseConfig + `
+kubernetes:
+  version: 1.17.9
+containerEngine:
+  type: cri-o
+`,
+			got: func(c *rundata.Cluster) interface{} {
+				return []string{c.ContainerEngine.CRIO.Version, c.Kubeadm.NodeRegistration.CRISocket}
+			},
+			want: []string{"1.17", constants.CRIOCRISocket},
+		},
+		{
+			name:   "network plugin and HA",
+			config: baseConfig,
+			got: func(c *rundata.Cluster) interface{} {
+				return []string{c.NetworkPlugins.Type, c.NetworkPlugins.Flannel.BackendType, c.HA.Type,
+					c.HA.LocalSLB.Type, c.HA.LocalSLB.Nginx.Port}
+			},
+			want: []string{constants.DefaulNetworkPlugin, constants.DefaultFlannelBackendType, constants.HATypeNone,
+				constants.LocalSLBTypeNginx, constants.DefaultNginxPort},
+		},
+		{
+			name:   "certificates and upgrade",
+			config: baseConfig,
+			got: func(c *rundata.Cluster) interface{} {
+				return []interface{}{c.CertNotAfterTime, c.KeyAlgorithm, c.Upgrade.WorkerBatchSize}
+			},
+			want: []interface{}{constants.DefaultCertNotAfterYear, constants.DefaultKeyAlgorithm, constants.DefaultUpgradeWorkerBatchSize},
+		},
+		{
+			name:   "proxy",
+			config: baseConfig + "proxy:\n  httpProxy: http://10.3.0.2:3128\n  noProxy: [.k8s.local]\n",
+			got: func(c *rundata.Cluster) interface{} {
+				return []interface{}{c.Proxy.HTTPSProxy, c.Proxy.AllNoProxy}
+			},
+			want: []interface{}{"http://10.3.0.2:3128", []string{"localhost", "127.0.0.1", ".k8s.local", "10.3.0.10", "10.3.0.20",
+				constants.DefaultPodNetworkCidr, constants.DefaultServiceSubnet, "apiserver.k8s.local"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.got(loadConfig(t, tt.config)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetDefaults() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:   "valid config",
+			config: baseConfig,
+		},
+		{
+			name: "no nodes",
+			config: `
+apiVersion: kubei.io/v1alpha1
+kind: Cluster
+ssh:
+  configFile: /nonexistent/kubei/ssh_config
+`,
+			wantErr: "nodes: Required value",
+		},
+		{
+			name:    "duplicate node",
+			config:  strings.Replace(baseConfig, "10.3.0.20", "10.3.0.10", 1),
+			wantErr: `nodes.workers[0].host: Duplicate value: "10.3.0.10:22"`,
+		},
+		{
+			name:    "master host is not an IP address",
+			config:  strings.Replace(baseConfig, "10.3.0.10", "master-1.k8s.local", 1),
+			wantErr: `nodes.masters[0].host: Invalid value: "master-1.k8s.local": must be an IP address`,
+		},
+		{
+			name:   "worker host is a host name",
+			config: strings.Replace(baseConfig, "10.3.0.20", "worker-1.k8s.local", 1),
+		},
+		{
+			name:    "invalid port",
+			config:  strings.Replace(baseConfig, "- host: 10.3.0.20", "- host: 10.3.0.20\n    port: 70000", 1),
+			wantErr: `nodes.workers[0].port: Invalid value: "70000"`,
+		},
+		{
+			name:    "unsupported auth method",
+			config:  baseConfig + "  authMethods: [gssapi]\n",
+			wantErr: `nodes.masters[0].authMethods[0]: Unsupported value: "gssapi"`,
+		},
+		{
+			name:    "unsupported host key policy",
+			config:  baseConfig + "  hostKeyPolicy: none\n",
+			wantErr: `ssh.hostKeyPolicy: Unsupported value: "none"`,
+		},
+		{
+			name:    "unsupported container engine",
+			config:  baseConfig + "containerEngine:\n  type: rkt\n",
+			wantErr: `containerEngine.type: Unsupported value: "rkt"`,
+		},
+		{
+			name:    "invalid registry mirror",
+			config:  baseConfig + "containerEngine:\n  registry:\n    mirrors: [mirror.example.com]\n",
+			wantErr: `containerEngine.registry.mirrors[0]: Invalid value: "mirror.example.com"`,
+		},
+		{
+			name:    "registry auth without password",
+			config:  baseConfig + "containerEngine:\n  registry:\n    auths:\n    - registry: harbor.k8s.local\n      username: admin\n",
+			wantErr: "containerEngine.registry.auths[0].password: Required value",
+		},
+		{
+			name:    "invalid proxy",
+			config:  baseConfig + "proxy:\n  httpProxy: socks5://10.3.0.2:1080\n",
+			wantErr: `proxy.httpProxy: Invalid value: "socks5://10.3.0.2:1080"`,
+		},
+		{
+			name:    "unsupported network plugin",
+			config:  baseConfig + "networkPlugin:\n  type: weave\n",
+			wantErr: `networkPlugin.type: Unsupported value: "weave"`,
+		},
+		{
+			name:    "unsupported HA",
+			config:  baseConfig + "ha:\n  type: keepalived\n",
+			wantErr: `ha.type: Unsupported value: "keepalived"`,
+		},
+		{
+			name:    "invalid certificates",
+			config:  baseConfig + "certificates:\n  notAfterYears: -1\n  keyAlgorithm: dsa\n",
+			wantErr: `certificates.notAfterYears: Invalid value: -1: must be greater than 0, certificates.keyAlgorithm: Unsupported value: "dsa"`,
+		},
+		{
+			name:    "invalid apiserver cert SAN",
+			config:  baseConfig + "kubernetes:\n  apiServerCertSANs: [api_server]\n",
+			wantErr: `kubernetes.apiServerCertSANs[0]: Invalid value: "api_server"`,
+		},
+		{
+			name:    "invalid worker batch size",
+			config:  baseConfig + "upgrade:\n  workerBatchSize: -1\n",
+			wantErr: "upgrade.workerBatchSize: Invalid value: -1: must be greater than 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(loadConfig(t, tt.config))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConvertFrom(t *testing.T) {
+	c := loadConfig(t, `
+apiVersion: kubei.io/v1alpha1
+kind: Cluster
+clusterName: test
+nodes:
+  masters:
+  - host: 10.3.0.10
+    password: master-password
+  workers:
+  - host: 10.3.0.20
+    key: /home/ops/.ssh/node.key
+    passphrase: node-passphrase
+    jumpServers:
+    - host: 10.3.0.2
+      password: jump-password
+ssh:
+  password: public-password
+  passphrase: public-passphrase
+  configFile: /nonexistent/kubei/ssh_config
+containerEngine:
+  type: containerd
+  registry:
+    auths:
+    - registry: harbor.k8s.local
+      username: admin
+      password: registry-password
+`)
+	c.Kubernetes.Token = rundata.Token{Token: "abcdef.0123456789abcdef", CaCertHash: "hash", CertificateKey: "key"}
+
+	b, err := Encode(ConvertFrom(c))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	for _, secret := range []string{"master-password", "node-passphrase", "jump-password", "public-password", "public-passphrase"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("ConvertFrom() keeps the secret %q:\n%s", secret, b)
+		}
+	}
+
+	cfg, err := Decode(b)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var hosts []v1alpha1.Host
+	hosts = append(hosts, cfg.Nodes.Masters...)
+	hosts = append(hosts, cfg.Nodes.Workers...)
+	for _, h := range cfg.Nodes.Workers {
+		hosts = append(hosts, h.JumpServers...)
+	}
+	for _, h := range hosts {
+		if h.Password != "" || h.Passphrase != "" {
+			t.Errorf("ConvertFrom() keeps the password or the passphrase of host %s", h.Host)
+		}
+	}
+
+	got := loadConfig(t, string(b))
+	if got.Kubeadm.ClusterName != "test" || got.ContainerEngine.Type != constants.ContainerEngineTypeContainerd {
+		t.Errorf("ConvertFrom() cluster name = %q and container engine = %q, want \"test\" and %q",
+			got.Kubeadm.ClusterName, got.ContainerEngine.Type, constants.ContainerEngineTypeContainerd)
+	}
+	if got.ClusterNodes.Workers[0].HostInfo.Key != "/home/ops/.ssh/node.key" || got.ClusterNodes.Workers[0].JumpServers[0].Host != "10.3.0.2" {
+		t.Errorf("ConvertFrom() worker = %+v, want the key and the jump server of the worker", got.ClusterNodes.Workers[0])
+	}
+	if !reflect.DeepEqual(got.Kubernetes.Token, c.Kubernetes.Token) {
+		t.Errorf("ConvertFrom() token = %+v, want %+v", got.Kubernetes.Token, c.Kubernetes.Token)
+	}
+	// the credentials of the registries are kept to join new nodes
+	if !reflect.DeepEqual(got.ContainerEngine.Registry.Auths, c.ContainerEngine.Registry.Auths) {
+		t.Errorf("ConvertFrom() registry auths = %+v, want %+v", got.ContainerEngine.Registry.Auths, c.ContainerEngine.Registry.Auths)
+	}
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	kubeadmapi "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm"
+
+	"github.com/yuyicai/kubei/internal/config/v1alpha1"
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/pkg/redact"
+)
+
+// Convert sets the cluster from the configuration file.
+func Convert(cfg *v1alpha1.Cluster, c *rundata.Cluster) {
+	convertNodes(&cfg.Nodes, &cfg.SSH, &c.ClusterNodes)
+	convertSSH(&cfg.SSH, c.Kubei)
+	convertKubernetes(cfg, c)
+	convertContainerEngine(&cfg.ContainerEngine, &c.ContainerEngine)
+	convertNetworkPlugin(&cfg.NetworkPlugin, &c.NetworkPlugins)
+	convertHA(&cfg.HA, &c.HA)
+
+	c.CertNotAfterTime = cfg.Certificates.NotAfterYears
+	c.CADir = cfg.Certificates.CADir
+	c.KeyAlgorithm = cfg.Certificates.KeyAlgorithm
+	c.OfflineFile = cfg.OfflineFile
+	c.Reset.RemoveContainerEngine = cfg.Reset.RemoveContainerEngine
+	c.Reset.RemoveKubeComponent = cfg.Reset.RemoveKubernetesComponent
+	c.Upgrade.WorkerBatchSize = cfg.Upgrade.WorkerBatchSize
+	c.Proxy = rundata.Proxy{
+		HTTPProxy:  cfg.Proxy.HTTPProxy,
+		HTTPSProxy: cfg.Proxy.HTTPSProxy,
+		NoProxy:    cfg.Proxy.NoProxy,
+	}
+
+	if cfg.Status != nil {
+		convertStatus(cfg.Status, c)
+	}
+}
+
+func convertStatus(s *v1alpha1.Status, c *rundata.Cluster) {
+	redact.Add(s.Token, s.CACertHash, s.CertificateKey)
+	c.Kubernetes.Token = rundata.Token{
+		Token:          s.Token,
+		CaCertHash:     s.CACertHash,
+		CertificateKey: s.CertificateKey,
+	}
+
+	nodes := map[string]*rundata.Node{}
+	for _, node := range c.ClusterNodes.GetAllNodes() {
+		nodes[node.Name] = node
+	}
+	for _, n := range s.Nodes {
+		if node, ok := nodes[n.Name]; ok {
+			node.InstallType = n.InstallType
+			node.PackageManagementType = n.PackageManagementType
+		}
+	}
+}
+
+func convertNodes(n *v1alpha1.Nodes, s *v1alpha1.SSH, c *rundata.ClusterNodes) {
+	for _, host := range n.Masters {
+		c.Masters = append(c.Masters, convertNode(host))
+	}
+	for _, host := range n.Workers {
+		c.Workers = append(c.Workers, convertNode(host))
+	}
+
+	c.PublicHostInfo = rundata.HostInfo{
+		User:        s.User,
+		Port:        convertPort(s.Port),
+		Password:    s.Password,
+		Key:         s.Key,
+		Passphrase:  s.Passphrase,
+		AuthMethods: s.AuthMethods,
+	}
+}
+
+func convertNode(h v1alpha1.Host) *rundata.Node {
+	node := &rundata.Node{
+		Name:     h.Name,
+		HostInfo: convertHostInfo(h),
+	}
+	for _, host := range h.JumpServers {
+		node.JumpServers = append(node.JumpServers, convertHostInfo(host))
+	}
+	return node
+}
+
+func convertHostInfo(h v1alpha1.Host) rundata.HostInfo {
+	return rundata.HostInfo{
+		Host:        h.Host,
+		User:        h.User,
+		Port:        convertPort(h.Port),
+		Password:    h.Password,
+		Key:         h.Key,
+		Passphrase:  h.Passphrase,
+		AuthMethods: h.AuthMethods,
+	}
+}
+
+func convertSSH(s *v1alpha1.SSH, k *rundata.Kubei) {
+	k.SSH.HostKeyPolicy = s.HostKeyPolicy
+	k.SSH.KnownHostsFile = s.KnownHostsFile
+	k.SSH.ConfigFile = s.ConfigFile
+	k.SSH.ProxyJump = s.ProxyJump
+
+	for _, host := range s.JumpServers {
+		k.JumpServers = append(k.JumpServers, convertHostInfo(host))
+	}
+}
+
+func convertKubernetes(cfg *v1alpha1.Cluster, c *rundata.Cluster) {
+	c.Kubernetes.Version = strings.Replace(cfg.Kubernetes.Version, "v", "", -1)
+
+	c.Kubeadm.ClusterName = cfg.ClusterName
+	c.Kubeadm.ControlPlaneEndpoint = cfg.Kubernetes.ControlPlaneEndpoint
+	c.Kubeadm.ImageRepository = cfg.Kubernetes.ImageRepository
+	c.Kubeadm.APIServer.CertSANs = cfg.Kubernetes.APIServerCertSANs
+	c.Kubeadm.APIServer.ExtraArgs = cfg.Kubernetes.APIServer.ExtraArgs
+	c.Kubeadm.ControllerManager.ExtraArgs = cfg.Kubernetes.ControllerManager.ExtraArgs
+	c.Kubeadm.Scheduler.ExtraArgs = cfg.Kubernetes.Scheduler.ExtraArgs
+	c.Kubeadm.FeatureGates = cfg.Kubernetes.FeatureGates
+	c.Kubeadm.KubeProxy = cfg.Kubernetes.KubeProxy
+	c.Kubeadm.Kubelet = cfg.Kubernetes.Kubelet
+	if len(cfg.Kubernetes.EtcdServerCertSANs) > 0 {
+		c.Kubeadm.Etcd.Local = &kubeadmapi.LocalEtcd{ServerCertSANs: cfg.Kubernetes.EtcdServerCertSANs}
+	}
+	c.Kubeadm.Networking.PodSubnet = cfg.Networking.PodSubnet
+	c.Kubeadm.Networking.ServiceSubnet = cfg.Networking.ServiceSubnet
+	c.Kubeadm.Networking.DNSDomain = cfg.Networking.DNSDomain
+	c.Kubeadm.ProxyMode = cfg.Networking.ProxyMode
+}
+
+func convertContainerEngine(e *v1alpha1.ContainerEngine, c *rundata.ContainerEngine) {
+	c.Type = e.Type
+	c.Docker = rundata.Docker{
+		Version:        strings.Replace(e.Docker.Version, "v", "", -1),
+		CGroupDriver:   e.Docker.CGroupDriver,
+		LogDriver:      e.Docker.LogDriver,
+		LogOptsMaxSize: e.Docker.LogOptsMaxSize,
+		StorageDriver:  e.Docker.StorageDriver,
+	}
+	c.Containerd = rundata.Containerd{
+		Version:      strings.Replace(e.Containerd.Version, "v", "", -1),
+		CGroupDriver: e.Containerd.CGroupDriver,
+	}
+	c.CRIO = rundata.CRIO{
+		Version:      strings.Replace(e.CRIO.Version, "v", "", -1),
+		CGroupDriver: e.CRIO.CGroupDriver,
+	}
+	c.Registry = rundata.Registry{
+		Mirrors:            e.Registry.Mirrors,
+		InsecureRegistries: e.Registry.InsecureRegistries,
+	}
+	for _, a := range e.Registry.Auths {
+		redact.Add(a.Password)
+		c.Registry.Auths = append(c.Registry.Auths, rundata.RegistryAuth{
+			Registry: a.Registry,
+			Username: a.Username,
+			Password: a.Password,
+		})
+	}
+}
+
+func convertNetworkPlugin(n *v1alpha1.NetworkPlugin, c *rundata.NetworkPlugins) {
+	c.Type = n.Type
+	c.Flannel.BackendType = n.Flannel.BackendType
+	c.Flannel.Image = convertImage(n.Flannel.Image)
+	c.Calico.Image = convertImage(n.Calico.Image)
+}
+
+func convertHA(h *v1alpha1.HA, c *rundata.HA) {
+	c.Type = h.Type
+	c.LocalSLB.Type = h.LocalSLB.Type
+	c.LocalSLB.Nginx.Port = convertPort(h.LocalSLB.Nginx.Port)
+	c.LocalSLB.Nginx.Image = convertImage(h.LocalSLB.Nginx.Image)
+}
+
+func convertImage(i v1alpha1.Image) rundata.Image {
+	return rundata.Image{
+		ImageRepository: i.Repository,
+		ImageName:       i.Name,
+		ImageTag:        i.Tag,
+	}
+}
+
+func convertPort(port int) string {
+	if port == 0 {
+		return ""
+	}
+	return strconv.Itoa(port)
+}
+
+// ConvertFrom converts the defaulted cluster back to the configuration file, it is used to write the state file.
+// The nodes and the jump servers are written as resolved, the passwords and the passphrases are not written.
+func ConvertFrom(c *rundata.Cluster) *v1alpha1.Cluster {
+	cfg := &v1alpha1.Cluster{
+		ClusterName: c.Kubeadm.ClusterName,
+		SSH: v1alpha1.SSH{
+			HostKeyPolicy:  c.SSH.HostKeyPolicy,
+			KnownHostsFile: c.SSH.KnownHostsFile,
+			ConfigFile:     c.SSH.ConfigFile,
+			JumpServers:    convertFromHostInfos(c.JumpServers),
+		},
+		Kubernetes: v1alpha1.Kubernetes{
+			Version:              c.Kubernetes.Version,
+			ControlPlaneEndpoint: c.Kubeadm.ControlPlaneEndpoint,
+			ImageRepository:      c.Kubeadm.ImageRepository,
+			APIServerCertSANs:    c.Kubeadm.APIServer.CertSANs,
+			EtcdServerCertSANs:   etcdServerCertSANs(c.Kubeadm),
+			APIServer:            v1alpha1.ControlPlaneComponent{ExtraArgs: c.Kubeadm.APIServer.ExtraArgs},
+			ControllerManager:    v1alpha1.ControlPlaneComponent{ExtraArgs: c.Kubeadm.ControllerManager.ExtraArgs},
+			Scheduler:            v1alpha1.ControlPlaneComponent{ExtraArgs: c.Kubeadm.Scheduler.ExtraArgs},
+			FeatureGates:         c.Kubeadm.FeatureGates,
+			KubeProxy:            c.Kubeadm.KubeProxy,
+			Kubelet:              c.Kubeadm.Kubelet,
+		},
+		Networking: v1alpha1.Networking{
+			PodSubnet:     c.Kubeadm.Networking.PodSubnet,
+			ServiceSubnet: c.Kubeadm.Networking.ServiceSubnet,
+			DNSDomain:     c.Kubeadm.Networking.DNSDomain,
+			ProxyMode:     c.Kubeadm.ProxyMode,
+		},
+		ContainerEngine: v1alpha1.ContainerEngine{
+			Type: c.ContainerEngine.Type,
+			Docker: v1alpha1.Docker{
+				Version:        c.ContainerEngine.Docker.Version,
+				CGroupDriver:   c.ContainerEngine.Docker.CGroupDriver,
+				LogDriver:      c.ContainerEngine.Docker.LogDriver,
+				LogOptsMaxSize: c.ContainerEngine.Docker.LogOptsMaxSize,
+				StorageDriver:  c.ContainerEngine.Docker.StorageDriver,
+			},
+			Containerd: v1alpha1.Containerd{
+				Version:      c.ContainerEngine.Containerd.Version,
+				CGroupDriver: c.ContainerEngine.Containerd.CGroupDriver,
+			},
+			CRIO: v1alpha1.CRIO{
+				Version:      c.ContainerEngine.CRIO.Version,
+				CGroupDriver: c.ContainerEngine.CRIO.CGroupDriver,
+			},
+			Registry: convertFromRegistry(c.ContainerEngine.Registry),
+		},
+		NetworkPlugin: v1alpha1.NetworkPlugin{
+			Type: c.NetworkPlugins.Type,
+			Flannel: v1alpha1.Flannel{
+				BackendType: c.NetworkPlugins.Flannel.BackendType,
+				Image:       convertFromImage(c.NetworkPlugins.Flannel.Image),
+			},
+			Calico: v1alpha1.Calico{
+				Image: convertFromImage(c.NetworkPlugins.Calico.Image),
+			},
+		},
+		HA: v1alpha1.HA{
+			Type: c.HA.Type,
+			LocalSLB: v1alpha1.LocalSLB{
+				Type: c.HA.LocalSLB.Type,
+				Nginx: v1alpha1.Nginx{
+					Port:  convertFromPort(c.HA.LocalSLB.Nginx.Port),
+					Image: convertFromImage(c.HA.LocalSLB.Nginx.Image),
+				},
+			},
+		},
+		Certificates: v1alpha1.Certificates{
+			NotAfterYears: c.CertNotAfterTime,
+			CADir:         c.CADir,
+			KeyAlgorithm:  c.KeyAlgorithm,
+		},
+		Reset: v1alpha1.Reset{
+			RemoveContainerEngine:     c.Reset.RemoveContainerEngine,
+			RemoveKubernetesComponent: c.Reset.RemoveKubeComponent,
+		},
+		Upgrade: v1alpha1.Upgrade{
+			WorkerBatchSize: c.Upgrade.WorkerBatchSize,
+		},
+		Proxy: v1alpha1.Proxy{
+			HTTPProxy:  c.Proxy.HTTPProxy,
+			HTTPSProxy: c.Proxy.HTTPSProxy,
+			NoProxy:    c.Proxy.NoProxy,
+		},
+		OfflineFile: c.OfflineFile,
+		Status: &v1alpha1.Status{
+			Token:          c.Kubernetes.Token.Token,
+			CACertHash:     c.Kubernetes.Token.CaCertHash,
+			CertificateKey: c.Kubernetes.Token.CertificateKey,
+		},
+	}
+	cfg.APIVersion = v1alpha1.APIVersion
+	cfg.Kind = v1alpha1.Kind
+
+	convertFromNodes := func(nodes []*rundata.Node, role string) []v1alpha1.Host {
+		var hosts []v1alpha1.Host
+		for _, node := range nodes {
+			host := convertFromHostInfo(node.HostInfo)
+			host.Name = node.Name
+			// the jump servers of the cluster take precedence over the ones of the nodes
+			if len(c.JumpServers) == 0 {
+				host.JumpServers = convertFromHostInfos(node.JumpServers)
+			}
+			hosts = append(hosts, host)
+
+			cfg.Status.Nodes = append(cfg.Status.Nodes, v1alpha1.NodeStatus{
+				Name:                  node.Name,
+				Role:                  role,
+				InstallType:           node.InstallType,
+				PackageManagementType: node.PackageManagementType,
+			})
+		}
+		return hosts
+	}
+	cfg.Nodes.Masters = convertFromNodes(c.ClusterNodes.Masters, constants.NodeRoleMaster)
+	cfg.Nodes.Workers = convertFromNodes(c.ClusterNodes.Workers, constants.NodeRoleWorker)
+
+	return cfg
+}
+
+func convertFromHostInfos(hostInfos []rundata.HostInfo) []v1alpha1.Host {
+	var hosts []v1alpha1.Host
+	for _, h := range hostInfos {
+		hosts = append(hosts, convertFromHostInfo(h))
+	}
+	return hosts
+}
+
+func convertFromHostInfo(h rundata.HostInfo) v1alpha1.Host {
+	return v1alpha1.Host{
+		Host:        h.Host,
+		User:        h.User,
+		Port:        convertFromPort(h.Port),
+		Key:         h.Key,
+		AuthMethods: h.AuthMethods,
+	}
+}
+
+// convertFromRegistry keeps the credentials of the registries, unlike the SSH passwords they are needed to join new nodes
+func convertFromRegistry(r rundata.Registry) v1alpha1.Registry {
+	registry := v1alpha1.Registry{
+		Mirrors:            r.Mirrors,
+		InsecureRegistries: r.InsecureRegistries,
+	}
+	for _, a := range r.Auths {
+		registry.Auths = append(registry.Auths, v1alpha1.RegistryAuth{
+			Registry: a.Registry,
+			Username: a.Username,
+			Password: a.Password,
+		})
+	}
+	return registry
+}
+
+func convertFromImage(i rundata.Image) v1alpha1.Image {
+	return v1alpha1.Image{
+		Repository: i.ImageRepository,
+		Name:       i.ImageName,
+		Tag:        i.ImageTag,
+	}
+}
+
+func convertFromPort(port string) int {
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+func etcdServerCertSANs(k *rundata.Kubeadm) []string {
+	if k.Etcd.Local == nil {
+		return nil
+	}
+	return k.Etcd.Local.ServerCertSANs
+}
@@ -0,0 +1,345 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"k8s.io/kubernetes/cmd/kubeadm/app/features"
+
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/pkg/redact"
+	"github.com/yuyicai/kubei/pkg/ssh"
+)
+
+// SetDefaults sets the fields that are not set, the nodes and the jump servers are resolved with the ssh_config file.
+func SetDefaults(c *rundata.Cluster) error {
+	sshCfg(&c.SSH)
+	if err := resolveHosts(c.Kubei); err != nil {
+		return err
+	}
+
+	addonsCfg(&c.Addons)
+	containerEngineCfg(&c.ContainerEngine, c.Kubernetes.Version)
+	networkPluginsCfg(&c.NetworkPlugins)
+	haCfg(&c.HA)
+	clusterNodesCfg(&c.ClusterNodes, c.OfflineFile)
+	jumpServersCfg(c.JumpServers)
+	certCfg(&c.CertNotAfterTime)
+	setToEmptyString(&c.KeyAlgorithm, constants.DefaultKeyAlgorithm)
+	upgradeCfg(&c.Upgrade)
+
+	kubeadmCfg(c.Kubeadm, c.Kubei)
+	proxyCfg(&c.Proxy, c.Kubei, c.Kubeadm)
+	return nil
+}
+
+func kubeadmCfg(k *rundata.Kubeadm, ki *rundata.Kubei) {
+	if k.LocalAPIEndpoint.BindPort == 0 {
+		k.LocalAPIEndpoint.BindPort = constants.DefaultAPIBindPort
+	}
+
+	setToEmptyString(&k.ClusterName, constants.DefaultClusterName)
+	setToEmptyString(&k.ControlPlaneEndpoint, constants.DefaultControlPlaneEndpoint)
+	setToEmptyString(&k.ImageRepository, constants.DefaultImageRepository)
+	setToEmptyString(&k.Networking.ServiceSubnet, constants.DefaultServiceSubnet)
+	setToEmptyString(&k.Networking.PodSubnet, constants.DefaultPodNetworkCidr)
+	setToEmptyString(&k.Networking.DNSDomain, "cluster.local")
+	if mode, ok := k.KubeProxy["mode"].(string); ok {
+		setToEmptyString(&k.ProxyMode, mode)
+	}
+	setToEmptyString(&k.ProxyMode, constants.DefaultProxyMode)
+
+	if len(ki.ClusterNodes.Masters) > 0 {
+		setToEmptyString(&k.LocalAPIEndpoint.AdvertiseAddress, ki.ClusterNodes.Masters[0].HostInfo.Host)
+	}
+
+	// kubeadm only detects Docker, the CRI socket and the cgroup driver of the kubelet are set for the other container engines
+	if socket := ki.ContainerEngine.CRISocket(); socket != "" {
+		setToEmptyString(&k.NodeRegistration.CRISocket, socket)
+		if _, ok := k.Kubelet["cgroupDriver"]; !ok {
+			if k.Kubelet == nil {
+				k.Kubelet = map[string]interface{}{}
+			}
+			k.Kubelet["cgroupDriver"] = ki.ContainerEngine.CGroupDriver()
+		}
+	}
+
+	// the key algorithm is set with the feature gate of kubeadm, so the certificates created by kubei and kubeadm use the same algorithm
+	if ki.KeyAlgorithm == constants.KeyAlgorithmECDSA {
+		if k.FeatureGates == nil {
+			k.FeatureGates = map[string]bool{}
+		}
+		k.FeatureGates[features.PublicKeysECDSA] = true
+	}
+
+}
+
+// resolveHosts sets the SSH host info of the nodes, in order of precedence: the node itself,
+// the public host info, the ssh_config file. The jump servers of ProxyJump are resolved with the ssh_config file too.
+func resolveHosts(k *rundata.Kubei) error {
+	sshConfig, err := ssh.LoadSSHConfig(k.SSH.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	public := k.ClusterNodes.PublicHostInfo
+	for _, node := range k.ClusterNodes.GetAllNodes() {
+		setToEmptyString(&node.HostInfo.Password, public.Password)
+		setToEmptyString(&node.HostInfo.User, public.User)
+		setToEmptyString(&node.HostInfo.Port, public.Port)
+		setToEmptyString(&node.HostInfo.Key, public.Key)
+		setToEmptyString(&node.HostInfo.Passphrase, public.Passphrase)
+		if len(node.HostInfo.AuthMethods) == 0 {
+			node.HostInfo.AuthMethods = public.AuthMethods
+		}
+		setToEmptyString(&node.Name, node.HostInfo.Host)
+
+		if err := resolveNode(node, sshConfig); err != nil {
+			return err
+		}
+	}
+
+	if k.SSH.ProxyJump != "" {
+		if len(k.JumpServers) > 0 {
+			return fmt.Errorf("the jump servers and the ProxyJump %q can not be set together", k.SSH.ProxyJump)
+		}
+		hops, err := sshConfig.ParseProxyJump(k.SSH.ProxyJump)
+		if err != nil {
+			return fmt.Errorf("invalid ProxyJump %q: %v", k.SSH.ProxyJump, err)
+		}
+		k.JumpServers = hostInfos(hops)
+	}
+	return nil
+}
+
+// resolveNode applies the ssh_config settings of the node, the host is used as the Host alias.
+// The settings that are already set take precedence over the ssh_config file.
+func resolveNode(node *rundata.Node, sshConfig *ssh.SSHConfig) error {
+	alias := node.HostInfo.Host
+	if alias == "" {
+		return nil
+	}
+
+	hc, err := sshConfig.Resolve(alias)
+	if err != nil {
+		return fmt.Errorf("invalid node %q: %v", alias, err)
+	}
+
+	node.HostInfo.Host = hc.HostName
+	setToEmptyString(&node.HostInfo.User, hc.User)
+	setToEmptyString(&node.HostInfo.Port, hc.Port)
+	setToEmptyString(&node.HostInfo.Key, hc.IdentityFile)
+
+	hops, err := sshConfig.ProxyJump(alias)
+	if err != nil {
+		return fmt.Errorf("invalid ProxyJump of node %q: %v", alias, err)
+	}
+	if len(node.JumpServers) == 0 {
+		node.JumpServers = hostInfos(hops)
+	}
+	return nil
+}
+
+func hostInfos(hops []ssh.HostConfig) []rundata.HostInfo {
+	var h []rundata.HostInfo
+	for _, hop := range hops {
+		h = append(h, rundata.HostInfo{
+			Host: hop.HostName,
+			User: hop.User,
+			Port: hop.Port,
+			Key:  hop.IdentityFile,
+		})
+	}
+	return h
+}
+
+// proxyCfg defaults the https proxy to the http proxy, and adds the addresses of the cluster to the hosts
+// that are not proxied, so the nodes, the Pods and the Services are reached directly
+func proxyCfg(p *rundata.Proxy, ki *rundata.Kubei, k *rundata.Kubeadm) {
+	if !p.IsSet() {
+		return
+	}
+	setToEmptyString(&p.HTTPSProxy, p.HTTPProxy)
+	for _, proxy := range []string{p.HTTPProxy, p.HTTPSProxy} {
+		if u, err := url.Parse(proxy); err == nil {
+			if password, ok := u.User.Password(); ok {
+				redact.Add(password)
+			}
+		}
+	}
+
+	noProxy := append([]string{"localhost", constants.LoopbackAddress}, p.NoProxy...)
+	for _, node := range ki.ClusterNodes.GetAllNodes() {
+		noProxy = append(noProxy, node.HostInfo.Host)
+	}
+	noProxy = append(noProxy, k.Networking.PodSubnet, k.Networking.ServiceSubnet)
+	if host, _, err := net.SplitHostPort(k.ControlPlaneEndpoint); err == nil {
+		noProxy = append(noProxy, host)
+	} else {
+		noProxy = append(noProxy, k.ControlPlaneEndpoint)
+	}
+
+	p.AllNoProxy = nil
+	for _, host := range noProxy {
+		if host != "" && !contains(p.AllNoProxy, host) {
+			p.AllNoProxy = append(p.AllNoProxy, host)
+		}
+	}
+}
+
+func addonsCfg(a *rundata.Addons) {
+}
+
+func clusterNodesCfg(c *rundata.ClusterNodes, offlineFile string) {
+	for _, node := range c.GetAllNodes() {
+		nodeCfg(node, offlineFile)
+	}
+}
+
+func nodeCfg(node *rundata.Node, offlineFile string) {
+	if node.InstallType == "" {
+		node.InstallType = constants.InstallTypeOnline
+		if offlineFile != "" {
+			node.InstallType = constants.InstallTypeOffline
+		}
+	}
+
+	hostInfoCfg(&node.HostInfo)
+	jumpServersCfg(node.JumpServers)
+}
+
+func jumpServersCfg(jumpServers []rundata.HostInfo) {
+	for i := range jumpServers {
+		hostInfoCfg(&jumpServers[i])
+	}
+}
+
+func hostInfoCfg(h *rundata.HostInfo) {
+	setToEmptyString(&h.User, constants.DefaultSSHUser)
+	setToEmptyString(&h.Port, constants.DefaultSSHPort)
+}
+
+func sshCfg(s *rundata.SSH) {
+	setToEmptyString(&s.HostKeyPolicy, constants.DefaultHostKeyPolicy)
+	setToEmptyString(&s.KnownHostsFile, ssh.DefaultKnownHostsFile())
+	setToEmptyString(&s.ConfigFile, ssh.DefaultSSHConfigFile())
+}
+
+func haCfg(h *rundata.HA) {
+	if h.Type == "" {
+		h.Type = constants.HATypeNone
+	}
+
+	localSLBCfg(&h.LocalSLB)
+}
+
+func networkPluginsCfg(n *rundata.NetworkPlugins) {
+	if n.Type == "" {
+		n.Type = constants.DefaulNetworkPlugin
+	}
+
+	flannelCfg(&n.Flannel)
+}
+
+func flannelCfg(f *rundata.Flannel) {
+	if f.BackendType == "" {
+		f.BackendType = constants.DefaultFlannelBackendType
+	}
+
+	if f.Image.ImageRepository == "" {
+		f.Image.ImageRepository = constants.DefaultFlannelImageRepository
+	}
+
+	if f.Image.ImageName == "" {
+		f.Image.ImageName = constants.DefaultFlannelImageName
+	}
+
+	if f.Image.ImageTag == "" {
+		f.Image.ImageTag = constants.DefaultFlannelVersion
+	}
+}
+
+func localSLBCfg(l *rundata.LocalSLB) {
+	if l.Type == "" {
+		l.Type = constants.LocalSLBTypeNginx
+	}
+
+	nginxCfg(&l.Nginx)
+}
+
+func nginxCfg(n *rundata.Nginx) {
+	if n.Port == "" {
+		n.Port = constants.DefaultNginxPort
+	}
+
+	if n.Image.ImageRepository == "" {
+		n.Image.ImageRepository = constants.DefaultNginxImageRepository
+	}
+
+	if n.Image.ImageName == "" {
+		n.Image.ImageName = constants.DefaultNginxImageName
+	}
+
+	if n.Image.ImageTag == "" {
+		n.Image.ImageTag = constants.DefaultNginxVersion
+	}
+}
+
+func containerEngineCfg(c *rundata.ContainerEngine, kubernetesVersion string) {
+	if c.Type == "" {
+		c.Type = constants.ContainerEngineTypeDocker
+	}
+
+	dockerCfg(&c.Docker)
+	setToEmptyString(&c.Containerd.CGroupDriver, constants.DefaultCGroupDriver)
+	crioCfg(&c.CRIO, kubernetesVersion)
+}
+
+func crioCfg(c *rundata.CRIO, kubernetesVersion string) {
+	setToEmptyString(&c.CGroupDriver, constants.DefaultCGroupDriver)
+
+	// CRI-O is released with the minor versions of Kubernetes
+	if v := strings.SplitN(kubernetesVersion, ".", 3); len(v) >= 2 {
+		setToEmptyString(&c.Version, v[0]+"."+v[1])
+	}
+	setToEmptyString(&c.Version, constants.DefaultCRIOVersion)
+}
+
+func dockerCfg(d *rundata.Docker) {
+	if d.CGroupDriver == "" {
+		d.CGroupDriver = constants.DefaultCGroupDriver
+	}
+
+	if d.LogDriver == "" {
+		d.LogDriver = constants.DefaultLogDriver
+	}
+
+	if d.LogOptsMaxSize == "" {
+		d.LogOptsMaxSize = constants.DefaultLogOptsMaxSize
+	}
+
+	if d.StorageDriver == "" {
+		d.StorageDriver = constants.DockerDefaultStorageDriver
+	}
+}
+
+func certCfg(t *int) {
+	if *t == 0 {
+		*t = constants.DefaultCertNotAfterYear
+	}
+}
+
+func upgradeCfg(u *rundata.Upgrade) {
+	if u.WorkerBatchSize == 0 {
+		u.WorkerBatchSize = constants.DefaultUpgradeWorkerBatchSize
+	}
+}
+
+func setToEmptyString(sp *string, s string) {
+	if *sp == "" {
+		*sp = s
+	}
+}
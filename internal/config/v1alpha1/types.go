@@ -0,0 +1,259 @@
+// Package v1alpha1 defines the v1alpha1 version of the kubei cluster configuration file.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	GroupName = "kubei.io"
+	Version   = "v1alpha1"
+	// APIVersion is the apiVersion of the configuration file
+	APIVersion = GroupName + "/" + Version
+	// Kind is the kind of the configuration file
+	Kind = "Cluster"
+)
+
+// Cluster is the configuration of a cluster managed by kubei. The fields that are not set take their default values,
+// and the flags of the command line take precedence over the file.
+type Cluster struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ClusterName is the name of the cluster, default "kubernetes"
+	ClusterName string `json:"clusterName,omitempty"`
+
+	Nodes           Nodes           `json:"nodes,omitempty"`
+	SSH             SSH             `json:"ssh,omitempty"`
+	Kubernetes      Kubernetes      `json:"kubernetes,omitempty"`
+	Networking      Networking      `json:"networking,omitempty"`
+	ContainerEngine ContainerEngine `json:"containerEngine,omitempty"`
+	NetworkPlugin   NetworkPlugin   `json:"networkPlugin,omitempty"`
+	HA              HA              `json:"ha,omitempty"`
+	Certificates    Certificates    `json:"certificates,omitempty"`
+	Addons          Addons          `json:"addons,omitempty"`
+	Reset           Reset           `json:"reset,omitempty"`
+	Upgrade         Upgrade         `json:"upgrade,omitempty"`
+	Proxy           Proxy           `json:"proxy,omitempty"`
+
+	// OfflineFile is the path to the offline package, the nodes are installed offline when it is set
+	OfflineFile string `json:"offlineFile,omitempty"`
+
+	// Status is written by kubei to the state file of the cluster, it is not set in a configuration file
+	Status *Status `json:"status,omitempty"`
+}
+
+// Status is the state of an initialized cluster.
+type Status struct {
+	Token          string `json:"token,omitempty"`
+	CACertHash     string `json:"caCertHash,omitempty"`
+	CertificateKey string `json:"certificateKey,omitempty"`
+
+	Nodes []NodeStatus `json:"nodes,omitempty"`
+}
+
+type NodeStatus struct {
+	Name string `json:"name"`
+	// Role is master or worker
+	Role                  string `json:"role"`
+	InstallType           string `json:"installType,omitempty"`
+	PackageManagementType string `json:"packageManagementType,omitempty"`
+}
+
+type Nodes struct {
+	Masters []Host `json:"masters,omitempty"`
+	Workers []Host `json:"workers,omitempty"`
+}
+
+// Host is a node or a jump server, the SSH settings that are not set fall back to the ssh section.
+type Host struct {
+	// Name is the name of the node, default the host
+	Name        string   `json:"name,omitempty"`
+	Host        string   `json:"host"`
+	User        string   `json:"user,omitempty"`
+	Port        int      `json:"port,omitempty"`
+	Password    string   `json:"password,omitempty"`
+	Key         string   `json:"key,omitempty"`
+	Passphrase  string   `json:"passphrase,omitempty"`
+	AuthMethods []string `json:"authMethods,omitempty"`
+
+	// JumpServers is the chain of jump servers of the node, it takes precedence over the ProxyJump of the ssh_config file
+	JumpServers []Host `json:"jumpServers,omitempty"`
+}
+
+type SSH struct {
+	// User, Port, Password, Key, Passphrase and AuthMethods are used by the nodes that do not set them
+	User        string   `json:"user,omitempty"`
+	Port        int      `json:"port,omitempty"`
+	Password    string   `json:"password,omitempty"`
+	Key         string   `json:"key,omitempty"`
+	Passphrase  string   `json:"passphrase,omitempty"`
+	AuthMethods []string `json:"authMethods,omitempty"`
+
+	// HostKeyPolicy is one of strict, accept-new and insecure, default accept-new
+	HostKeyPolicy  string `json:"hostKeyPolicy,omitempty"`
+	KnownHostsFile string `json:"knownHostsFile,omitempty"`
+	// ConfigFile is the ssh_config file used to resolve the nodes, default ~/.ssh/config
+	ConfigFile string `json:"configFile,omitempty"`
+
+	// JumpServers is the chain of jump servers to reach the nodes through, in connecting order.
+	// ProxyJump is the same chain in the ssh -J form "[user@]host[:port],...", only one of them can be set.
+	JumpServers []Host `json:"jumpServers,omitempty"`
+	ProxyJump   string `json:"proxyJump,omitempty"`
+}
+
+type Kubernetes struct {
+	Version              string `json:"version,omitempty"`
+	ControlPlaneEndpoint string `json:"controlPlaneEndpoint,omitempty"`
+	ImageRepository      string `json:"imageRepository,omitempty"`
+	// APIServerCertSANs are the extra Subject Alternative Names of the apiserver certificate, IPs or DNS names
+	APIServerCertSANs []string `json:"apiServerCertSANs,omitempty"`
+	// EtcdServerCertSANs are the extra Subject Alternative Names of the etcd server certificate, IPs or DNS names
+	EtcdServerCertSANs []string `json:"etcdServerCertSANs,omitempty"`
+	// APIServer, ControllerManager and Scheduler hold the settings of the control plane components
+	APIServer         ControlPlaneComponent `json:"apiServer,omitempty"`
+	ControllerManager ControlPlaneComponent `json:"controllerManager,omitempty"`
+	Scheduler         ControlPlaneComponent `json:"scheduler,omitempty"`
+	// FeatureGates are the feature gates of kubeadm
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+	// KubeProxy is the KubeProxyConfiguration (kubeproxy.config.k8s.io/v1alpha1) of the cluster, apiVersion and kind can be omitted
+	KubeProxy map[string]interface{} `json:"kubeProxy,omitempty"`
+	// Kubelet is the KubeletConfiguration (kubelet.config.k8s.io/v1beta1) of the cluster, apiVersion and kind can be omitted
+	Kubelet map[string]interface{} `json:"kubelet,omitempty"`
+}
+
+type ControlPlaneComponent struct {
+	// ExtraArgs are the extra flags of the component, without the leading dashes
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+}
+
+type Networking struct {
+	PodSubnet     string `json:"podSubnet,omitempty"`
+	ServiceSubnet string `json:"serviceSubnet,omitempty"`
+	DNSDomain     string `json:"dnsDomain,omitempty"`
+	// ProxyMode is the mode of kube-proxy: iptables or ipvs, default iptables.
+	// It takes precedence over the mode of kubernetes.kubeProxy
+	ProxyMode string `json:"proxyMode,omitempty"`
+}
+
+type ContainerEngine struct {
+	// Type is one of docker, containerd and cri-o, default docker
+	Type       string     `json:"type,omitempty"`
+	Docker     Docker     `json:"docker,omitempty"`
+	Containerd Containerd `json:"containerd,omitempty"`
+	CRIO       CRIO       `json:"crio,omitempty"`
+	Registry   Registry   `json:"registry,omitempty"`
+}
+
+// Registry is the registry settings of the container engine, they are applied to docker, containerd and cri-o.
+type Registry struct {
+	// Mirrors are the mirrors of docker.io, default the mirrors of nwafu and 163. An empty list disables the mirrors
+	Mirrors []string `json:"mirrors"`
+	// InsecureRegistries are the registries (host[:port]) that are pulled without verifying their certificates, or over http
+	InsecureRegistries []string `json:"insecureRegistries,omitempty"`
+	// Auths are the credentials of the private registries, they are written to the container engine and to the kubelet
+	Auths []RegistryAuth `json:"auths,omitempty"`
+}
+
+type RegistryAuth struct {
+	// Registry is the host[:port] of the registry, e.g. harbor.example.com
+	Registry string `json:"registry"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Docker struct {
+	Version        string `json:"version,omitempty"`
+	CGroupDriver   string `json:"cgroupDriver,omitempty"`
+	LogDriver      string `json:"logDriver,omitempty"`
+	LogOptsMaxSize string `json:"logOptsMaxSize,omitempty"`
+	StorageDriver  string `json:"storageDriver,omitempty"`
+}
+
+type Containerd struct {
+	Version string `json:"version,omitempty"`
+	// CGroupDriver is one of cgroupfs and systemd, default cgroupfs. The kubelet is set to the same driver
+	CGroupDriver string `json:"cgroupDriver,omitempty"`
+}
+
+type CRIO struct {
+	// Version is the minor version of CRI-O, default the minor version of Kubernetes
+	Version string `json:"version,omitempty"`
+	// CGroupDriver is the cgroup manager of CRI-O: cgroupfs or systemd, default cgroupfs. The kubelet is set to the same driver
+	CGroupDriver string `json:"cgroupDriver,omitempty"`
+}
+
+type NetworkPlugin struct {
+	// Type is one of flannel, calico and none, default flannel
+	Type    string  `json:"type,omitempty"`
+	Flannel Flannel `json:"flannel,omitempty"`
+	Calico  Calico  `json:"calico,omitempty"`
+}
+
+type Flannel struct {
+	BackendType string `json:"backendType,omitempty"`
+	Image       Image  `json:"image,omitempty"`
+}
+
+type Calico struct {
+	Image Image `json:"image,omitempty"`
+}
+
+type HA struct {
+	// Type is one of none and local, default none
+	Type     string   `json:"type,omitempty"`
+	LocalSLB LocalSLB `json:"localSLB,omitempty"`
+}
+
+type LocalSLB struct {
+	// Type is the load balancer on each worker, only nginx is supported
+	Type  string `json:"type,omitempty"`
+	Nginx Nginx  `json:"nginx,omitempty"`
+}
+
+type Nginx struct {
+	Port  int   `json:"port,omitempty"`
+	Image Image `json:"image,omitempty"`
+}
+
+type Image struct {
+	Repository string `json:"repository,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+}
+
+type Certificates struct {
+	// NotAfterYears is the validity of the certificates in years, default 10
+	NotAfterYears int `json:"notAfterYears,omitempty"`
+	// CADir is a local directory holding the CAs of the cluster with the layout of /etc/kubernetes/pki:
+	// ca.crt and ca.key, and optionally front-proxy-ca.crt/.key and etcd/ca.crt/.key.
+	// A CA without its key is an external CA, the certificates it signs must be on the masters before kubeadm runs
+	CADir string `json:"caDir,omitempty"`
+	// KeyAlgorithm is the algorithm of the keys of the certificates and the service account key: rsa (2048 bits) or ecdsa (P-256), default rsa
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+}
+
+// Addons is reserved for the addons of the cluster. kubei installs no addon yet, so it has no field
+// and any field set in it is rejected as unknown.
+type Addons struct {
+}
+
+type Reset struct {
+	RemoveContainerEngine     bool `json:"removeContainerEngine,omitempty"`
+	RemoveKubernetesComponent bool `json:"removeKubernetesComponent,omitempty"`
+}
+
+type Upgrade struct {
+	// WorkerBatchSize is the number of workers that are drained and upgraded at the same time
+	WorkerBatchSize int `json:"workerBatchSize,omitempty"`
+}
+
+// Proxy is the proxy of the nodes, it is used by the online installation and by the container engine to pull the images
+type Proxy struct {
+	// HTTPProxy is the proxy of the http requests, e.g. http://proxy.example.com:3128
+	HTTPProxy string `json:"httpProxy,omitempty"`
+	// HTTPSProxy is the proxy of the https requests, default the http proxy
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	// NoProxy are the hosts, domains and CIDRs that are not proxied. The nodes, the pod and service subnets
+	// and the control plane endpoint are always added
+	NoProxy []string `json:"noProxy,omitempty"`
+}
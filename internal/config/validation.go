@@ -0,0 +1,294 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/kubernetes/cmd/kubeadm/app/features"
+
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/pkg/ssh"
+)
+
+// Validate validates the defaulted cluster, the field paths of the errors are the paths in the configuration file.
+func Validate(c *rundata.Cluster) error {
+	allErrs := field.ErrorList{}
+	allErrs = append(allErrs, validateClusterNodes(&c.ClusterNodes, field.NewPath("nodes"))...)
+	allErrs = append(allErrs, validateSSH(c.Kubei, field.NewPath("ssh"))...)
+	allErrs = append(allErrs, validateKubeadm(c.Kubeadm)...)
+	allErrs = append(allErrs, validateContainerEngine(&c.ContainerEngine, field.NewPath("containerEngine"))...)
+	allErrs = append(allErrs, validateProxy(&c.Proxy, field.NewPath("proxy"))...)
+	allErrs = append(allErrs, validateNetworkPlugins(&c.NetworkPlugins, field.NewPath("networkPlugin"))...)
+	allErrs = append(allErrs, validateHA(&c.HA, field.NewPath("ha"))...)
+
+	if c.CertNotAfterTime <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("certificates", "notAfterYears"), c.CertNotAfterTime, "must be greater than 0"))
+	}
+
+	keyAlgorithms := []string{constants.KeyAlgorithmRSA, constants.KeyAlgorithmECDSA}
+	if !contains(keyAlgorithms, c.KeyAlgorithm) {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("certificates", "keyAlgorithm"), c.KeyAlgorithm, keyAlgorithms))
+	}
+
+	if c.Upgrade.WorkerBatchSize <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("upgrade", "workerBatchSize"), c.Upgrade.WorkerBatchSize, "must be greater than 0"))
+	}
+
+	return allErrs.ToAggregate()
+}
+
+func validateClusterNodes(c *rundata.ClusterNodes, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if len(c.GetAllNodes()) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one node is required"))
+	}
+
+	hosts := map[string]bool{}
+	names := map[string]bool{}
+	validate := func(nodes []*rundata.Node, fldPath *field.Path) {
+		for i, node := range nodes {
+			idxPath := fldPath.Index(i)
+			allErrs = append(allErrs, validateHostInfo(&node.HostInfo, idxPath)...)
+			for j := range node.JumpServers {
+				allErrs = append(allErrs, validateHostInfo(&node.JumpServers[j], idxPath.Child("jumpServers").Index(j))...)
+			}
+
+			address := net.JoinHostPort(node.HostInfo.Host, node.HostInfo.Port)
+			if hosts[address] {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("host"), address))
+			}
+			hosts[address] = true
+
+			if names[node.Name] {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), node.Name))
+			}
+			names[node.Name] = true
+		}
+	}
+	validate(c.Masters, fldPath.Child("masters"))
+	validate(c.Workers, fldPath.Child("workers"))
+
+	// the certificates and the advertise address of the masters need the IP addresses of their hosts,
+	// a host name of the ssh_config file is not resolved
+	for i, master := range c.Masters {
+		if master.HostInfo.Host != "" && net.ParseIP(master.HostInfo.Host) == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("masters").Index(i).Child("host"), master.HostInfo.Host,
+				"must be an IP address, the certificates of the master are signed for it"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateHostInfo(h *rundata.HostInfo, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if h.Host == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("host"), ""))
+	}
+
+	allErrs = append(allErrs, validatePort(h.Port, fldPath.Child("port"))...)
+
+	for i, method := range h.AuthMethods {
+		if !contains(ssh.DefaultAuthMethods, method) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("authMethods").Index(i), method, ssh.DefaultAuthMethods))
+		}
+	}
+	return allErrs
+}
+
+func validateSSH(k *rundata.Kubei, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	policies := []string{ssh.HostKeyPolicyStrict, ssh.HostKeyPolicyAcceptNew, ssh.HostKeyPolicyInsecure}
+	if !contains(policies, k.SSH.HostKeyPolicy) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("hostKeyPolicy"), k.SSH.HostKeyPolicy, policies))
+	}
+
+	for i := range k.JumpServers {
+		allErrs = append(allErrs, validateHostInfo(&k.JumpServers[i], fldPath.Child("jumpServers").Index(i))...)
+	}
+	return allErrs
+}
+
+func validateKubeadm(k *rundata.Kubeadm) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if _, _, err := net.SplitHostPort(k.ControlPlaneEndpoint); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("kubernetes", "controlPlaneEndpoint"), k.ControlPlaneEndpoint, "must be in the form host:port"))
+	}
+
+	allErrs = append(allErrs, validateCertSANs(k.APIServer.CertSANs, field.NewPath("kubernetes", "apiServerCertSANs"))...)
+	if k.Etcd.Local != nil {
+		allErrs = append(allErrs, validateCertSANs(k.Etcd.Local.ServerCertSANs, field.NewPath("kubernetes", "etcdServerCertSANs"))...)
+	}
+
+	for name := range k.FeatureGates {
+		if _, ok := features.InitFeatureGates[name]; !ok {
+			allErrs = append(allErrs, field.NotSupported(field.NewPath("kubernetes", "featureGates").Key(name), name, features.Keys(features.InitFeatureGates)))
+		}
+	}
+
+	networkingPath := field.NewPath("networking")
+	if _, _, err := net.ParseCIDR(k.Networking.PodSubnet); err != nil {
+		allErrs = append(allErrs, field.Invalid(networkingPath.Child("podSubnet"), k.Networking.PodSubnet, err.Error()))
+	}
+	if _, _, err := net.ParseCIDR(k.Networking.ServiceSubnet); err != nil {
+		allErrs = append(allErrs, field.Invalid(networkingPath.Child("serviceSubnet"), k.Networking.ServiceSubnet, err.Error()))
+	}
+	proxyModes := []string{constants.ProxyModeIPTables, constants.ProxyModeIPVS}
+	if !contains(proxyModes, k.ProxyMode) {
+		allErrs = append(allErrs, field.NotSupported(networkingPath.Child("proxyMode"), k.ProxyMode, proxyModes))
+	}
+	return allErrs
+}
+
+func validateCertSANs(sans []string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, san := range sans {
+		if net.ParseIP(san) != nil {
+			continue
+		}
+		if len(validation.IsDNS1123Subdomain(san)) > 0 && len(validation.IsWildcardDNS1123Subdomain(san)) > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), san, "must be a valid IP address or a RFC-1123 compliant DNS name"))
+		}
+	}
+	return allErrs
+}
+
+func validateContainerEngine(c *rundata.ContainerEngine, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	types := []string{constants.ContainerEngineTypeDocker, constants.ContainerEngineTypeContainerd, constants.ContainerEngineTypeCRIO}
+	if !contains(types, c.Type) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), c.Type, types))
+	}
+
+	drivers := []string{"cgroupfs", "systemd"}
+	if !contains(drivers, c.Docker.CGroupDriver) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("docker", "cgroupDriver"), c.Docker.CGroupDriver, drivers))
+	}
+	if !contains(drivers, c.Containerd.CGroupDriver) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("containerd", "cgroupDriver"), c.Containerd.CGroupDriver, drivers))
+	}
+	if !contains(drivers, c.CRIO.CGroupDriver) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("crio", "cgroupDriver"), c.CRIO.CGroupDriver, drivers))
+	}
+	allErrs = append(allErrs, validateRegistry(&c.Registry, fldPath.Child("registry"))...)
+	return allErrs
+}
+
+func validateRegistry(r *rundata.Registry, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, mirror := range r.Mirrors {
+		if u, err := url.Parse(mirror); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("mirrors").Index(i), mirror, "must be a http or https URL"))
+		}
+	}
+	for i, registry := range r.InsecureRegistries {
+		if !isRegistryHost(registry) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("insecureRegistries").Index(i), registry, "must be a registry host[:port] without scheme"))
+		}
+	}
+
+	authsPath := fldPath.Child("auths")
+	var registries []string
+	for i, a := range r.Auths {
+		switch {
+		case !isRegistryHost(a.Registry):
+			allErrs = append(allErrs, field.Invalid(authsPath.Index(i).Child("registry"), a.Registry, "must be a registry host[:port] without scheme"))
+		case contains(registries, a.Registry):
+			allErrs = append(allErrs, field.Duplicate(authsPath.Index(i).Child("registry"), a.Registry))
+		}
+		registries = append(registries, a.Registry)
+		if a.Username == "" {
+			allErrs = append(allErrs, field.Required(authsPath.Index(i).Child("username"), ""))
+		}
+		// the password is not shown in the errors
+		if a.Password == "" {
+			allErrs = append(allErrs, field.Required(authsPath.Index(i).Child("password"), ""))
+		}
+	}
+	return allErrs
+}
+
+func validateProxy(p *rundata.Proxy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateProxyURL(p.HTTPProxy, fldPath.Child("httpProxy"))...)
+	allErrs = append(allErrs, validateProxyURL(p.HTTPSProxy, fldPath.Child("httpsProxy"))...)
+	for i, host := range p.NoProxy {
+		if host == "" || strings.ContainsAny(host, " ,'\"\\") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("noProxy").Index(i), host, "must be a host, a domain or a CIDR"))
+		}
+	}
+	return allErrs
+}
+
+// validateProxyURL checks the proxy before it is quoted in the scripts and in the systemd drop-ins
+func validateProxyURL(proxy string, fldPath *field.Path) field.ErrorList {
+	if proxy == "" {
+		return nil
+	}
+	if u, err := url.Parse(proxy); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(proxy, " '\"\\") {
+		return field.ErrorList{field.Invalid(fldPath, proxy, "must be a http or https URL")}
+	}
+	return nil
+}
+
+func isRegistryHost(registry string) bool {
+	return registry != "" && !strings.ContainsAny(registry, "/ \"\\")
+}
+
+func validateNetworkPlugins(n *rundata.NetworkPlugins, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	types := []string{"flannel", "calico", "none"}
+	if !contains(types, n.Type) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), n.Type, types))
+	}
+
+	backends := []string{"vxlan", "host-gw", "udp"}
+	if !contains(backends, n.Flannel.BackendType) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("flannel", "backendType"), n.Flannel.BackendType, backends))
+	}
+	return allErrs
+}
+
+func validateHA(h *rundata.HA, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	types := []string{constants.HATypeNone, constants.HATypeLocalSLB}
+	if !contains(types, h.Type) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), h.Type, types))
+	}
+
+	slbPath := fldPath.Child("localSLB")
+	slbTypes := []string{constants.LocalSLBTypeNginx}
+	if !contains(slbTypes, h.LocalSLB.Type) {
+		allErrs = append(allErrs, field.NotSupported(slbPath.Child("type"), h.LocalSLB.Type, slbTypes))
+	}
+	allErrs = append(allErrs, validatePort(h.LocalSLB.Nginx.Port, slbPath.Child("nginx", "port"))...)
+	return allErrs
+}
+
+func validatePort(port string, fldPath *field.Path) field.ErrorList {
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return field.ErrorList{field.Invalid(fldPath, port, "must be between 1 and 65535")}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
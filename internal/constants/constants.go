@@ -6,6 +6,8 @@ const (
 	// ssh
 	DefaultSSHUser = "root"
 	DefaultSSHPort = "22"
+	// DefaultHostKeyPolicy records the host keys of unknown hosts and rejects changed host keys
+	DefaultHostKeyPolicy = "accept-new"
 
 	InstallTypeOffline       = "offline"
 	InstallTypeOnline        = "online"
@@ -18,23 +20,43 @@ const (
 	ContainerEngineTypeDocker     = "docker"
 	ContainerEngineTypeContainerd = "containerd"
 	ContainerEngineTypeCRIO       = "cri-o"
-	RegistryMirrors               = "https://dockerhub.mirrors.nwafu.edu.cn/"
+	ContainerdCRISocket           = "/run/containerd/containerd.sock"
+	CRIOCRISocket                 = "/var/run/crio/crio.sock"
 	DefaultCGroupDriver           = "cgroupfs"
 	DefaultLogDriver              = "json-file"
 	DefaultLogOptsMaxSize         = "500m"
 	DockerDefaultStorageDriver    = "overlay2"
+	// DefaultCRIOVersion is the minor version of CRI-O when the Kubernetes version is not set, CRI-O follows the minor versions of Kubernetes
+	DefaultCRIOVersion = "1.18"
+	// DefaultRegistryMirrors are the comma separated mirrors of docker.io used when the mirrors are not set
+	DefaultRegistryMirrors = "https://dockerhub.mirrors.nwafu.edu.cn/,https://hub-mirror.c.163.com"
+	// KubeletCredentialFile is the docker config file read by the kubelet and by CRI-O to pull images from the private registries
+	KubeletCredentialFile = "/var/lib/kubelet/config.json"
+	DockerCredentialFile  = "/root/.docker/config.json"
+	// DockerDaemonConfigFile is merged with the settings of kubei, the other settings of the file are kept
+	DockerDaemonConfigFile = "/etc/docker/daemon.json"
 
 	// kubeadm
 	DefaultServiceSubnet        = "10.96.0.0/12"
 	DefaultPodNetworkCidr       = "10.244.0.0/16"
+	ProxyModeIPTables           = "iptables"
+	ProxyModeIPVS               = "ipvs"
+	DefaultProxyMode            = ProxyModeIPTables
 	DefaultControlPlaneEndpoint = "apiserver.k8s.local:6443"
 	DefaultImageRepository      = "k8s.gcr.io"
 	DefaultAPIBindPort          = 6443
 	DefaultClusterName          = "kubernetes"
 	DefaultWaitNodeInterval     = 2 * time.Second
 	DefaultWaitNodeTimeout      = 6 * time.Minute
+	// DefaultWaitAPIServerTimeout is the time to wait for the apiserver of a master after its static Pods are restarted
+	DefaultWaitAPIServerTimeout = 5 * time.Minute
 	DefaultCertNotAfterYear     = 10
 	DefaultCertNotAfterTime     = Year * DefaultCertNotAfterYear
+	KeyAlgorithmRSA             = "rsa"
+	KeyAlgorithmECDSA           = "ecdsa"
+	DefaultKeyAlgorithm         = KeyAlgorithmRSA
+	// DefaultUpgradeWorkerBatchSize is the number of workers that are drained and upgraded at the same time
+	DefaultUpgradeWorkerBatchSize = 1
 
 	// networking plugin
 	DefaulNetworkPlugin           = "flannel"
@@ -56,6 +78,13 @@ const (
 
 	LoopbackAddress = "127.0.0.1"
 
+	// state
+	NodeRoleMaster = "master"
+	NodeRoleWorker = "worker"
+	StateDir       = ".kubei/clusters"
+	StateFileName  = "state.yaml"
+	StatePKIDir    = "pki"
+
 	DefaultGOMAXPROCS = 20
 
 	Day  = 24 * time.Hour
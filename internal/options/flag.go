@@ -1,8 +1,11 @@
 package options
 
 import (
+	"fmt"
+
 	flag "github.com/spf13/pflag"
 	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/pkg/ssh"
 )
 
 const (
@@ -10,25 +13,49 @@ const (
 	ShortKey                  = "k"
 	Port                      = "port"
 	Password                  = "password"
+	KeyPassphrase             = "key-passphrase"
+	AuthMethods               = "auth-methods"
 	ShortPassword             = "p"
 	User                      = "user"
 	KubernetesVersion         = "kubernetes-version"
+	ContainerEngineType       = "container-engine"
 	ContainerEngineVersion    = "container-engine-version"
+	RegistryMirrors           = "registry-mirrors"
+	InsecureRegistries        = "insecure-registries"
+	HTTPProxy                 = "http-proxy"
+	HTTPSProxy                = "https-proxy"
+	NoProxy                   = "no-proxy"
 	ControlPlaneEndpoint      = "control-plane-endpoint"
 	ImageRepository           = "image-repository"
 	Masters                   = "masters"
 	ShortMasters              = "m"
+	Master                    = "master"
+	ControlPlane              = "control-plane"
 	Workers                   = "nodes"
 	ShortNodes                = "n"
 	PodNetworkCidr            = "pod-network-cidr"
 	ServiceCidr               = "service-cidr"
+	ProxyMode                 = "proxy-mode"
 	JumpServer                = "jump-server"
 	RemoveContainerEngine     = "remove-container-engine"
 	RemoveKubernetesComponent = "remove-kubernetes-component"
 	OfflineFile               = "offline-file"
 	ShortOfflineFile          = "f"
 	CertNotAfterTime          = "cert-time"
+	CADir                     = "ca-dir"
+	KeyAlgorithm              = "key-algorithm"
+	APIServerCertExtraSANs    = "apiserver-cert-extra-sans"
+	EtcdServerCertExtraSANs   = "etcd-server-cert-extra-sans"
 	NetworkPlugin             = "network-plugin"
+	HostKeyPolicy             = "host-key-policy"
+	KnownHosts                = "known-hosts"
+	Config                    = "config"
+	Cluster                   = "cluster"
+	DryRun                    = "dry-run"
+	DryRunDir                 = "dry-run-dir"
+	SSHConfig                 = "ssh-config"
+	ProxyJump                 = "proxy-jump"
+	WorkerBatchSize           = "worker-batch-size"
 )
 
 func AddResetFlags(flagSet *flag.FlagSet, options *Reset) {
@@ -43,29 +70,81 @@ func AddResetFlags(flagSet *flag.FlagSet, options *Reset) {
 	)
 }
 
+func AddUpgradeFlags(flagSet *flag.FlagSet, options *Upgrade) {
+	flagSet.IntVar(
+		&options.WorkerBatchSize, WorkerBatchSize, options.WorkerBatchSize,
+		fmt.Sprintf("Number of workers that are drained and upgraded at the same time (default %d)", constants.DefaultUpgradeWorkerBatchSize),
+	)
+}
+
 func AddContainerEngineConfigFlags(flagSet *flag.FlagSet, options *ContainerEngine) {
+	flagSet.StringVar(
+		&options.Type, ContainerEngineType, options.Type,
+		fmt.Sprintf("The container engine: docker, containerd or cri-o (default %q)", constants.ContainerEngineTypeDocker),
+	)
 	flagSet.StringVar(
 		&options.Version, ContainerEngineVersion, options.Version,
-		"The Docker version.",
+		"The version of the container engine.",
+	)
+	flagSet.StringSliceVar(
+		&options.RegistryMirrors, RegistryMirrors, options.RegistryMirrors,
+		fmt.Sprintf("The mirrors of docker.io, \"--%s=\" disables the mirrors (default %q)", RegistryMirrors, constants.DefaultRegistryMirrors),
+	)
+	flagSet.StringSliceVar(
+		&options.InsecureRegistries, InsecureRegistries, options.InsecureRegistries,
+		"The registries (host[:port]) that are pulled without verifying their certificates, or over http.",
+	)
+}
+
+func AddProxyFlags(flagSet *flag.FlagSet, options *Proxy) {
+	flagSet.StringVar(
+		&options.HTTPProxy, HTTPProxy, options.HTTPProxy,
+		"The proxy of the http requests of the online installation and the container engine, e.g. http://proxy.example.com:3128",
+	)
+	flagSet.StringVar(
+		&options.HTTPSProxy, HTTPSProxy, options.HTTPSProxy,
+		fmt.Sprintf("The proxy of the https requests, default the value of --%s", HTTPProxy),
+	)
+	flagSet.StringSliceVar(
+		&options.NoProxy, NoProxy, options.NoProxy,
+		"The hosts, domains and CIDRs that are not proxied, the nodes, the pod and service subnets and the control plane endpoint are always added",
 	)
 }
 
 func AddKubeClusterNodesConfigFlags(flagSet *flag.FlagSet, options *ClusterNodes) {
 	flagSet.StringSliceVarP(
 		&options.Masters, Masters, ShortMasters, options.Masters,
-		"The master nodes IP",
+		"The master nodes IP, the SSH host info of a node can follow its IP, e.g. \"10.0.0.5;user=centos;port=2222;key=/path/to/key\"",
 	)
 
 	flagSet.StringSliceVarP(
 		&options.Workers, Workers, ShortNodes, options.Workers,
-		"The worker nodes IP",
+		"The worker nodes IP, the SSH host info of a node can follow its IP, e.g. \"10.0.0.5;user=centos;port=2222;key=/path/to/key\"",
+	)
+}
+
+func AddJoinNodesConfigFlags(flagSet *flag.FlagSet, options *ClusterNodes) {
+	flagSet.StringSliceVar(
+		&options.Masters, Master, options.Masters,
+		"The master nodes IP of the existing cluster, the bootstrap token is created on the first one. All the masters are needed to set up the local SLB of the nodes",
+	)
+
+	flagSet.StringSliceVarP(
+		&options.Workers, Workers, ShortNodes, options.Workers,
+		"The worker nodes IP to join the cluster, the SSH host info of a node can follow its IP, e.g. \"10.0.0.5;user=centos;port=2222;key=/path/to/key\"",
+	)
+}
+
+func AddJoinControlPlaneFlags(flagSet *flag.FlagSet, controlPlane *bool) {
+	flagSet.BoolVar(controlPlane, ControlPlane, *controlPlane,
+		"Join the nodes of --nodes as masters. The certificates are created with the CAs saved by kubei if the cluster is loaded by --cluster, otherwise they are uploaded by kubeadm",
 	)
 }
 
 func AddPublicUserInfoConfigFlags(flagSet *flag.FlagSet, options *PublicHostInfo) {
 	flagSet.StringVar(
-		&options.User, User, constants.DefaultSSHUser,
-		"SSH user of the nodes.",
+		&options.User, User, options.User,
+		"SSH user of the nodes. (default \"root\" if it is not set in the ssh_config file)",
 	)
 
 	flagSet.StringVarP(
@@ -74,51 +153,102 @@ func AddPublicUserInfoConfigFlags(flagSet *flag.FlagSet, options *PublicHostInfo
 	)
 
 	flagSet.StringVar(
-		&options.Port, Port, constants.DefaultSSHPort,
-		"SSH port of the nodes.",
+		&options.Port, Port, options.Port,
+		"SSH port of the nodes. (default \"22\" if it is not set in the ssh_config file)",
 	)
 
 	flagSet.StringVarP(
 		&options.Key, Key, ShortKey, options.Key,
 		"SSH key of the nodes.",
 	)
+
+	flagSet.StringVar(
+		&options.Passphrase, KeyPassphrase, options.Passphrase,
+		"Passphrase of the SSH key. If the key is encrypted and no passphrase is set, kubei prompts for it.",
+	)
+
+	flagSet.StringSliceVar(
+		&options.AuthMethods, AuthMethods, options.AuthMethods,
+		"SSH authentication methods tried in order: agent, key, password, keyboard-interactive. (default all methods that have credentials)",
+	)
 }
 
-func AddKubeadmConfigFlags(flagSet *flag.FlagSet, options *Kubeadm) {
+func AddSSHConfigFlags(flagSet *flag.FlagSet, options *SSH) {
+	flagSet.StringVar(
+		&options.HostKeyPolicy, HostKeyPolicy, options.HostKeyPolicy,
+		fmt.Sprintf("How to verify the SSH host keys of the nodes and the jump server: strict, accept-new or insecure. (default %q)", constants.DefaultHostKeyPolicy),
+	)
+
+	flagSet.StringVar(
+		&options.KnownHostsFile, KnownHosts, options.KnownHostsFile,
+		fmt.Sprintf("Path to the known_hosts file used to verify the SSH host keys. (default %q)", ssh.DefaultKnownHostsFile()),
+	)
+
+	flagSet.StringVar(
+		&options.ConfigFile, SSHConfig, options.ConfigFile,
+		fmt.Sprintf("Path to the ssh_config file used to resolve the Host, HostName, User, Port, IdentityFile and ProxyJump of the nodes. (default %q)", ssh.DefaultSSHConfigFile()),
+	)
+
+	flagSet.StringVar(
+		&options.ProxyJump, ProxyJump, options.ProxyJump,
+		"Chain of jump servers to reach the nodes through, in connecting order, e.g. \"ops@bastion:2222,10.0.0.1\". It takes precedence over the ProxyJump of the ssh_config file.",
+	)
+}
+
+func AddConfigFileFlags(flagSet *flag.FlagSet, configFile *string) {
+	flagSet.StringVar(configFile, Config, *configFile,
+		"Path to a kubei configuration file (apiVersion: kubei.io/v1alpha1, kind: Cluster), the flags take precedence over the file",
+	)
+}
+
+func AddClusterFlags(flagSet *flag.FlagSet, name *string) {
+	flagSet.StringVar(name, Cluster, *name,
+		"Name of a cluster created by kubei, the cluster is loaded from its state file in ~/.kubei/clusters/<name> instead of the --config file",
+	)
+}
+
+func AddKubernetesConfigFlags(flagSet *flag.FlagSet, options *Kubernetes) {
 	flagSet.StringVar(
 		&options.Version, KubernetesVersion, options.Version,
 		"The Kubernetes version",
 	)
+}
 
+func AddKubeadmConfigFlags(flagSet *flag.FlagSet, options *Kubeadm) {
 	flagSet.StringVar(
-		&options.Networking.ServiceSubnet, ServiceCidr, constants.DefaultServiceSubnet,
-		"Use alternative range of IP address for service VIPs",
+		&options.Networking.ServiceSubnet, ServiceCidr, options.Networking.ServiceSubnet,
+		fmt.Sprintf("Use alternative range of IP address for service VIPs (default %q)", constants.DefaultServiceSubnet),
 	)
 	flagSet.StringVar(
-		&options.Networking.PodSubnet, PodNetworkCidr, constants.DefaultPodNetworkCidr,
-		"Specify range of IP addresses for the pod network",
+		&options.Networking.PodSubnet, PodNetworkCidr, options.Networking.PodSubnet,
+		fmt.Sprintf("Specify range of IP addresses for the pod network (default %q)", constants.DefaultPodNetworkCidr),
+	)
+	flagSet.StringVar(
+		&options.Networking.ProxyMode, ProxyMode, options.Networking.ProxyMode,
+		fmt.Sprintf("Mode of kube-proxy: iptables or ipvs, the IPVS kernel modules, ipset and ipvsadm are set up on the nodes for ipvs (default %q)", constants.DefaultProxyMode),
 	)
 
 	AddImageMetaFlags(flagSet, &options.ImageRepository)
 	AddControlPlaneEndpointFlags(flagSet, options)
+	AddCertSANsFlags(flagSet, options)
 }
 
 func AddControlPlaneEndpointFlags(flagSet *flag.FlagSet, options *Kubeadm) {
 	flagSet.StringVar(
-		&options.ControlPlaneEndpoint, ControlPlaneEndpoint, constants.DefaultControlPlaneEndpoint,
-		`Specify a DNS name for the control plane.`,
+		&options.ControlPlaneEndpoint, ControlPlaneEndpoint, options.ControlPlaneEndpoint,
+		fmt.Sprintf("Specify a DNS name for the control plane. (default %q)", constants.DefaultControlPlaneEndpoint),
 	)
 }
 
 func AddImageMetaFlags(flagSet *flag.FlagSet, imageRepository *string) {
-	flagSet.StringVar(imageRepository, ImageRepository, constants.DefaultImageRepository,
-		"Choose a container registry to pull control plane images from",
+	flagSet.StringVar(imageRepository, ImageRepository, *imageRepository,
+		fmt.Sprintf("Choose a container registry to pull control plane images from (default %q)", constants.DefaultImageRepository),
 	)
 }
 
 func AddJumpServerFlags(flagSet *flag.FlagSet, userInfo *map[string]string) {
 	flagSet.StringToStringVar(userInfo, JumpServer, *userInfo,
-		"Jump server user info",
+		"Jump server user info, e.g. \"host=IP,port=22,user=your-user,key=key-path,auth-methods=agent;key\"",
 	)
 }
 
@@ -129,13 +259,48 @@ func AddOfflinePackageFlags(flagSet *flag.FlagSet, pkg *string) {
 }
 
 func AddCertNotAfterTimeFlags(flagSet *flag.FlagSet, year *int) {
-	flagSet.IntVar(year, CertNotAfterTime, constants.DefaultCertNotAfterYear,
-		"cert not after time, time units is year",
+	flagSet.IntVar(year, CertNotAfterTime, *year,
+		fmt.Sprintf("cert not after time, time units is year (default %d)", constants.DefaultCertNotAfterYear),
+	)
+}
+
+func AddCertSANsFlags(flagSet *flag.FlagSet, options *Kubeadm) {
+	flagSet.StringSliceVar(
+		&options.APIServerCertSANs, APIServerCertExtraSANs, options.APIServerCertSANs,
+		"Optional extra Subject Alternative Names (SANs) to use for the API Server serving certificate. Can be both IP addresses and DNS names.",
+	)
+
+	flagSet.StringSliceVar(
+		&options.EtcdServerCertSANs, EtcdServerCertExtraSANs, options.EtcdServerCertSANs,
+		"Optional extra Subject Alternative Names (SANs) to use for the etcd server certificate. Can be both IP addresses and DNS names.",
+	)
+}
+
+func AddCADirFlags(flagSet *flag.FlagSet, dir *string) {
+	flagSet.StringVar(dir, CADir, *dir,
+		"Path to a directory holding the CAs of the cluster: ca.crt and ca.key, and optionally front-proxy-ca.crt/.key and etcd/ca.crt/.key. "+
+			"The certificates are signed with these CAs instead of new ones, a CA without its key is an external CA",
+	)
+}
+
+func AddKeyAlgorithmFlags(flagSet *flag.FlagSet, algorithm *string) {
+	flagSet.StringVar(algorithm, KeyAlgorithm, *algorithm,
+		fmt.Sprintf("algorithm of the keys of the certificates and the service account key: rsa or ecdsa (default %q)", constants.DefaultKeyAlgorithm),
 	)
 }
 
 func AddNetworkPluginFlags(flagSet *flag.FlagSet, networkType *string) {
-	flagSet.StringVar(networkType, NetworkPlugin, constants.DefaulNetworkPlugin,
-		"network plugin",
+	flagSet.StringVar(networkType, NetworkPlugin, *networkType,
+		fmt.Sprintf("network plugin: flannel, calico or none (default %q)", constants.DefaulNetworkPlugin),
+	)
+}
+
+func AddDryRunFlags(flagSet *flag.FlagSet, dryRun *bool, dir *string) {
+	flagSet.BoolVar(dryRun, DryRun, *dryRun,
+		"Connect to the nodes for facts, but only print the scripts that would run on each node, in order",
+	)
+
+	flagSet.StringVar(dir, DryRunDir, *dir,
+		"Write the scripts of the dry run to a file per node in this directory instead of printing them",
 	)
 }
@@ -1,6 +1,8 @@
 package options
 
 import (
+	kubeadmapi "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm"
+
 	"github.com/yuyicai/kubei/internal/rundata"
 )
 
@@ -13,6 +15,17 @@ func (c *Kubeadm) ApplyTo(data *rundata.Kubeadm) {
 		data.ImageRepository = c.ImageRepository
 	}
 
+	if len(c.APIServerCertSANs) > 0 {
+		data.APIServer.CertSANs = c.APIServerCertSANs
+	}
+
+	if len(c.EtcdServerCertSANs) > 0 {
+		if data.Etcd.Local == nil {
+			data.Etcd.Local = &kubeadmapi.LocalEtcd{}
+		}
+		data.Etcd.Local.ServerCertSANs = c.EtcdServerCertSANs
+	}
+
 	c.Networking.ApplyTo(data)
 }
 
@@ -24,4 +37,8 @@ func (c *Networking) ApplyTo(data *rundata.Kubeadm) {
 	if c.PodSubnet != "" {
 		data.Networking.PodSubnet = c.PodSubnet
 	}
+
+	if c.ProxyMode != "" {
+		data.ProxyMode = c.ProxyMode
+	}
 }
@@ -1,42 +1,101 @@
 package options
 
 import (
-	"github.com/yuyicai/kubei/internal/constants"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/mitchellh/mapstructure"
+
+	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/internal/rundata"
-	"k8s.io/klog"
 )
 
-func (c *ClusterNodes) ApplyTo(data *rundata.ClusterNodes) {
+// ApplyTo replaces the nodes of the config file with the nodes of the flags,
+// and overrides the public host info of the config file.
+func (c *ClusterNodes) ApplyTo(data *rundata.ClusterNodes) error {
 
-	setNodesHost(&data.Masters, c.Masters)
-	setNodesHost(&data.Workers, c.Workers)
-	nodes := append(data.Masters, data.Workers...)
-
-	for _, v := range nodes {
-		if v.HostInfo.Password == "" && c.PublicHostInfo.Password != "" {
-			v.HostInfo.Password = c.PublicHostInfo.Password
-		}
-		if v.HostInfo.User == "" && c.PublicHostInfo.User != "" {
-			v.HostInfo.User = c.PublicHostInfo.User
+	if len(c.Masters) > 0 {
+		data.Masters = nil
+		if err := setNodesHost(&data.Masters, c.Masters); err != nil {
+			return fmt.Errorf("invalid --%s: %v", Masters, err)
 		}
-		if v.HostInfo.Port == "" && c.PublicHostInfo.Port != "" {
-			v.HostInfo.Port = c.PublicHostInfo.Port
-		}
-		if v.HostInfo.Key == "" && c.PublicHostInfo.Key != "" {
-			v.HostInfo.Key = c.PublicHostInfo.Key
-		}
-		if v.Name == "" {
-			v.Name = v.HostInfo.Host
+	}
+	if len(c.Workers) > 0 {
+		data.Workers = nil
+		if err := setNodesHost(&data.Workers, c.Workers); err != nil {
+			return fmt.Errorf("invalid --%s: %v", Workers, err)
 		}
 	}
+
+	c.PublicHostInfo.ApplyTo(&data.PublicHostInfo)
+	return nil
+}
+
+func (p *PublicHostInfo) ApplyTo(data *rundata.HostInfo) {
+	if p.Password != "" {
+		data.Password = p.Password
+	}
+	if p.User != "" {
+		data.User = p.User
+	}
+	if p.Port != "" {
+		data.Port = p.Port
+	}
+	if p.Key != "" {
+		data.Key = p.Key
+	}
+	if p.Passphrase != "" {
+		data.Passphrase = p.Passphrase
+	}
+	if len(p.AuthMethods) > 0 {
+		data.AuthMethods = p.AuthMethods
+	}
 }
 
 func (c *ContainerEngine) ApplyTo(data *rundata.ContainerEngine) {
+	if c.Type != "" {
+		data.Type = c.Type
+	}
+
 	if c.Version != "" {
-		data.Docker.Version = strings.Replace(c.Version, "v", "", -1)
+		version := strings.Replace(c.Version, "v", "", -1)
+		switch data.Type {
+		case constants.ContainerEngineTypeContainerd:
+			data.Containerd.Version = version
+		case constants.ContainerEngineTypeCRIO:
+			data.CRIO.Version = version
+		default:
+			data.Docker.Version = version
+		}
+	}
+
+	// the mirrors are empty but not nil when they are disabled with an empty flag
+	if c.RegistryMirrors != nil {
+		data.Registry.Mirrors = c.RegistryMirrors
+	}
+
+	if len(c.InsecureRegistries) > 0 {
+		data.Registry.InsecureRegistries = c.InsecureRegistries
+	}
+}
+
+func (s *SSH) ApplyTo(data *rundata.SSH) {
+	if s.HostKeyPolicy != "" {
+		data.HostKeyPolicy = s.HostKeyPolicy
+	}
+
+	if s.KnownHostsFile != "" {
+		data.KnownHostsFile = s.KnownHostsFile
+	}
+
+	if s.ConfigFile != "" {
+		data.ConfigFile = s.ConfigFile
+	}
+
+	if s.ProxyJump != "" {
+		data.ProxyJump = s.ProxyJump
 	}
 }
 
@@ -56,45 +115,153 @@ func (r *Reset) ApplyTo(data *rundata.Reset) {
 	}
 }
 
-func (k *Kubei) ApplyTo(data *rundata.Kubei) {
+func (u *Upgrade) ApplyTo(data *rundata.Upgrade) {
+	if u.WorkerBatchSize != 0 {
+		data.WorkerBatchSize = u.WorkerBatchSize
+	}
+}
+
+func (p *Proxy) ApplyTo(data *rundata.Proxy) {
+	if p.HTTPProxy != "" {
+		data.HTTPProxy = p.HTTPProxy
+	}
+
+	if p.HTTPSProxy != "" {
+		data.HTTPSProxy = p.HTTPSProxy
+	}
+
+	if len(p.NoProxy) > 0 {
+		data.NoProxy = p.NoProxy
+	}
+}
+
+// ApplyTo overrides the config file with the flags that are set.
+func (k *Kubei) ApplyTo(data *rundata.Kubei) error {
 
 	k.ContainerEngine.ApplyTo(&data.ContainerEngine)
-	k.ClusterNodes.ApplyTo(&data.ClusterNodes)
+	k.Kubernetes.ApplyTo(&data.Kubernetes)
+	if err := k.ClusterNodes.ApplyTo(&data.ClusterNodes); err != nil {
+		return err
+	}
+	k.SSH.ApplyTo(&data.SSH)
 	k.Reset.ApplyTo(&data.Reset)
+	k.Upgrade.ApplyTo(&data.Upgrade)
+	k.Proxy.ApplyTo(&data.Proxy)
+
+	if len(k.JumpServer) > 0 && k.SSH.ProxyJump != "" {
+		return fmt.Errorf("--%s and --%s can not be used together", JumpServer, ProxyJump)
+	}
 
+	// the jump servers of the flags replace the jump servers of the config file
 	if len(k.JumpServer) > 0 {
-		if err := mapstructure.Decode(k.JumpServer, &data.JumpServer.HostInfo); err != nil {
-			klog.Fatal(err)
+		hostInfo := rundata.HostInfo{}
+		if err := decodeJumpServer(k.JumpServer, &hostInfo); err != nil {
+			return fmt.Errorf("invalid --%s: %v", JumpServer, err)
 		}
+		data.JumpServers = []rundata.HostInfo{hostInfo}
+		data.SSH.ProxyJump = ""
+	}
+	if k.SSH.ProxyJump != "" {
+		data.JumpServers = nil
 	}
 
 	if k.OfflineFile != "" {
 		data.OfflineFile = k.OfflineFile
-		setNodesInstallType(data.ClusterNodes.GetAllNodes())
 	}
 
-	data.NetworkPlugins.Type = k.NetworkType
+	if k.NetworkType != "" {
+		data.NetworkPlugins.Type = k.NetworkType
+	}
+
+	if k.CertNotAfterTime != 0 {
+		data.CertNotAfterTime = k.CertNotAfterTime
+	}
+
+	if k.CADir != "" {
+		data.CADir = k.CADir
+	}
+
+	if k.KeyAlgorithm != "" {
+		data.KeyAlgorithm = k.KeyAlgorithm
+	}
 
-	data.CertNotAfterTime = k.CertNotAfterTime
+	data.DryRun.Enabled = k.DryRun || k.DryRunDir != ""
+	data.DryRun.Dir = k.DryRunDir
+	return nil
 }
 
-func setNodesHost(nodes *[]*rundata.Node, optionsNodes []string) {
-	if len(optionsNodes) > 0 {
-		for _, v := range optionsNodes {
-			v = strings.Replace(v, " ", "", -1)
-			vv := strings.Split(v, ";")
-			node := &rundata.Node{}
-			node.HostInfo.Host = vv[0]
-			if len(vv) > 1 {
-				//TODO set nodes ssh host info (host,user,port,password,key) with --masters and --workers
-			}
-			*nodes = append(*nodes, node)
+// decodeJumpServer decodes the --jump-server flag, the auth-methods are separated by ";",
+// e.g. "host=10.0.0.1,user=ops,auth-methods=agent;key,key=/path/to/key".
+func decodeJumpServer(m map[string]string, hostInfo *rundata.HostInfo) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:  mapstructure.StringToSliceHookFunc(";"),
+		ErrorUnused: true,
+		Result:      hostInfo,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(m)
+}
+
+// setNodesHost parses the nodes of --masters and --workers, the SSH host info of a node can follow its host,
+// e.g. "10.0.0.5;user=centos;port=2222;key=/path/to/key".
+func setNodesHost(nodes *[]*rundata.Node, optionsNodes []string) error {
+	for _, v := range optionsNodes {
+		node, err := parseNodeHost(v)
+		if err != nil {
+			return err
 		}
+		*nodes = append(*nodes, node)
 	}
+	return nil
 }
 
-func setNodesInstallType(nodes []*rundata.Node) {
-	for _, node := range nodes {
-		node.InstallType = constants.InstallTypeOffline
+func parseNodeHost(s string) (*rundata.Node, error) {
+	vv := strings.Split(s, ";")
+	node := &rundata.Node{}
+	node.HostInfo.Host = strings.TrimSpace(vv[0])
+	if node.HostInfo.Host == "" {
+		return nil, errors.New("the host of a node is empty")
+	}
+
+	set := map[string]bool{}
+	for _, field := range vv[1:] {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field %q of node %s, the format is key=value", field, node.HostInfo.Host)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if value == "" {
+			return nil, fmt.Errorf("the %s of node %s is empty", key, node.HostInfo.Host)
+		}
+		if set[key] {
+			return nil, fmt.Errorf("the %s of node %s is set more than once", key, node.HostInfo.Host)
+		}
+		set[key] = true
+
+		switch key {
+		case "user":
+			node.HostInfo.User = value
+		case "port":
+			if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+				return nil, fmt.Errorf("invalid port %q of node %s, the port must be between 1 and 65535", value, node.HostInfo.Host)
+			}
+			node.HostInfo.Port = value
+		case "password":
+			node.HostInfo.Password = value
+		case "key":
+			node.HostInfo.Key = value
+		case "passphrase":
+			node.HostInfo.Passphrase = value
+		default:
+			return nil, fmt.Errorf("unknown field %q of node %s, supported fields: user, port, password, key, passphrase", key, node.HostInfo.Host)
+		}
 	}
+	return node, nil
 }
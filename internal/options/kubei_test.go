@@ -0,0 +1,88 @@
+package options
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+func TestParseNodeHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    rundata.HostInfo
+		wantErr string
+	}{
+		{
+			name: "host",
+			s:    "10.3.0.10",
+			want: rundata.HostInfo{Host: "10.3.0.10"},
+		},
+		{
+			name: "host with all fields",
+			s:    " 10.3.0.10 ; user=centos;port=2222;password=p=w;key=/home/ops/.ssh/node.key;passphrase=secret;",
+			want: rundata.HostInfo{
+				Host:       "10.3.0.10",
+				User:       "centos",
+				Port:       "2222",
+				Password:   "p=w",
+				Key:        "/home/ops/.ssh/node.key",
+				Passphrase: "secret",
+			},
+		},
+		{
+			name:    "empty host",
+			s:       " ;user=centos",
+			wantErr: "the host of a node is empty",
+		},
+		{
+			name:    "field without value",
+			s:       "10.3.0.10;centos",
+			wantErr: `invalid field "centos" of node 10.3.0.10, the format is key=value`,
+		},
+		{
+			name:    "empty value",
+			s:       "10.3.0.10;user= ",
+			wantErr: "the user of node 10.3.0.10 is empty",
+		},
+		{
+			name:    "duplicate field",
+			s:       "10.3.0.10;port=22;port=2222",
+			wantErr: "the port of node 10.3.0.10 is set more than once",
+		},
+		{
+			name:    "invalid port",
+			s:       "10.3.0.10;port=ssh",
+			wantErr: `invalid port "ssh" of node 10.3.0.10`,
+		},
+		{
+			name:    "port out of range",
+			s:       "10.3.0.10;port=65536",
+			wantErr: `invalid port "65536" of node 10.3.0.10`,
+		},
+		{
+			name:    "unknown field",
+			s:       "10.3.0.10;name=master-1",
+			wantErr: `unknown field "name" of node 10.3.0.10`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNodeHost(tt.s)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseNodeHost() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseNodeHost() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.HostInfo, tt.want) {
+				t.Errorf("parseNodeHost() got = %+v, want %+v", got.HostInfo, tt.want)
+			}
+		})
+	}
+}
@@ -1,21 +1,32 @@
 package options
 
 type Kubeadm struct {
-	Version              string
 	ControlPlaneEndpoint string
 	ImageRepository      string
 	Networking           Networking
+	APIServerCertSANs    []string
+	EtcdServerCertSANs   []string
 }
 
 type Kubei struct {
+	ConfigFile       string
+	Cluster          string
 	Reset            Reset
+	Upgrade          Upgrade
+	Proxy            Proxy
 	ClusterNodes     ClusterNodes
+	SSH              SSH
 	ContainerEngine  ContainerEngine
 	Kubernetes       Kubernetes
 	JumpServer       map[string]string
 	OfflineFile      string
 	CertNotAfterTime int
+	CADir            string
+	KeyAlgorithm     string
 	NetworkType      string
+	DryRun           bool
+	DryRunDir        string
+	ControlPlane     bool
 }
 
 type Kubernetes struct {
@@ -23,10 +34,12 @@ type Kubernetes struct {
 }
 
 type PublicHostInfo struct {
-	Key      string
-	User     string
-	Password string
-	Port     string
+	Key         string
+	User        string
+	Password    string
+	Port        string
+	Passphrase  string
+	AuthMethods []string
 }
 
 type ClusterNodes struct {
@@ -36,8 +49,18 @@ type ClusterNodes struct {
 	Workers []string
 }
 
+type SSH struct {
+	HostKeyPolicy  string
+	KnownHostsFile string
+	ConfigFile     string
+	ProxyJump      string
+}
+
 type ContainerEngine struct {
-	Version string
+	Type               string
+	Version            string
+	RegistryMirrors    []string
+	InsecureRegistries []string
 }
 
 type JumpServerHostInfo struct {
@@ -50,9 +73,20 @@ type Reset struct {
 	RemoveKubeComponent   bool
 }
 
+type Upgrade struct {
+	WorkerBatchSize int
+}
+
+type Proxy struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    []string
+}
+
 type Networking struct {
 	ServiceSubnet string
 	PodSubnet     string
+	ProxyMode     string
 }
 
 func NewKubei() *Kubei {
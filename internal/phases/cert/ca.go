@@ -0,0 +1,129 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog"
+	kubeadmconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
+	kubeadmpkiutil "k8s.io/kubernetes/cmd/kubeadm/app/util/pkiutil"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+// LoadCAs loads the CAs of the cluster from the directory with the layout of /etc/kubernetes/pki.
+// ca.crt is needed, the front-proxy and etcd CAs are created by kubei if they are not in the directory.
+// A CA without its key is an external CA: kubei does not sign the certificates of an external CA,
+// they must be in the directory too and kubei sends them to the masters.
+func LoadCAs(dir string) (rundata.Certificates, error) {
+	var cas rundata.Certificates
+	for _, ca := range []rundata.Cert{rundata.CertRootCA, rundata.CertFrontProxyCA, rundata.CertEtcdCA} {
+		ca := ca
+		if _, err := os.Stat(filepath.Join(dir, ca.BaseName+".crt")); os.IsNotExist(err) {
+			if ca.Name == rundata.CertRootCA.Name {
+				return nil, fmt.Errorf("[cert] The CA %s is not found in %s", ca.Name, dir)
+			}
+			klog.V(2).Infof("[cert] The CA %s is not found in %s, it is created by kubei", ca.Name, dir)
+			continue
+		}
+
+		var err error
+		if ca.Cert, err = kubeadmpkiutil.TryLoadCertFromDisk(dir, ca.BaseName); err != nil {
+			return nil, fmt.Errorf("[cert] Failed to load the CA %s: %v", ca.Name, err)
+		}
+		if !ca.Cert.IsCA {
+			return nil, fmt.Errorf("[cert] The certificate %s.crt is not a CA", ca.BaseName)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ca.BaseName+".key")); os.IsNotExist(err) {
+			klog.V(2).Infof("[cert] The key of the CA %s is not found in %s, it is an external CA", ca.Name, dir)
+			var missing []string
+			for _, file := range externalCAFiles(&ca) {
+				if _, err := os.Stat(filepath.Join(dir, file)); os.IsNotExist(err) {
+					missing = append(missing, file)
+				}
+			}
+			if len(missing) > 0 {
+				return nil, fmt.Errorf("[cert] The CA %s is an external CA as its key is not found in %s, the files signed by it are missing in %s: %s",
+					ca.Name, dir, dir, strings.Join(missing, ", "))
+			}
+			cas = append(cas, &ca)
+			continue
+		}
+
+		if ca.Key, err = kubeadmpkiutil.TryLoadKeyFromDisk(dir, ca.BaseName); err != nil {
+			return nil, fmt.Errorf("[cert] Failed to load the key of the CA %s: %v", ca.Name, err)
+		}
+		if err := checkKeyMatchesCert(ca.Cert, ca.Key); err != nil {
+			return nil, fmt.Errorf("[cert] The key of the CA %s does not match its certificate: %v", ca.Name, err)
+		}
+		cas = append(cas, &ca)
+	}
+	return cas, nil
+}
+
+// externalCAFiles returns the files signed by the CA that kubei can not create if the CA is an external CA,
+// relative to the CA directory: the certificates and the keys with the layout of /etc/kubernetes/pki, and the kubeconfig files.
+func externalCAFiles(ca *rundata.Cert) []string {
+	var files []string
+	for _, cert := range rundata.GetDefaultCertList() {
+		if cert.CAName != ca.Name {
+			continue
+		}
+		if cert.IsKubeConfig {
+			files = append(files, cert.BaseName)
+			continue
+		}
+		files = append(files, cert.BaseName+".crt", cert.BaseName+".key")
+	}
+	// kubeadm init needs the kubelet.conf of the first master, which kubei never creates
+	if ca.Name == rundata.CertRootCA.Name {
+		files = append(files, kubeadmconstants.KubeletKubeConfigFileName)
+	}
+	return files
+}
+
+// LoadServiceAccountKey loads sa.key and sa.pub from the directory with the layout of /etc/kubernetes/pki,
+// the key pair is empty if one of them is not in the directory.
+func LoadServiceAccountKey(dir string) (rundata.KeyPair, error) {
+	var sa rundata.KeyPair
+	for _, f := range []struct {
+		name string
+		data *[]byte
+	}{
+		{name: kubeadmconstants.ServiceAccountPrivateKeyName, data: &sa.Key},
+		{name: kubeadmconstants.ServiceAccountPublicKeyName, data: &sa.PublicKey},
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.name))
+		if os.IsNotExist(err) {
+			klog.V(2).Infof("[cert] The service account key %s is not found in %s", f.name, dir)
+			return rundata.KeyPair{}, nil
+		}
+		if err != nil {
+			return rundata.KeyPair{}, fmt.Errorf("[cert] Failed to load the service account key %s: %v", f.name, err)
+		}
+		*f.data = data
+	}
+	return sa, nil
+}
+
+func checkKeyMatchesCert(cert *x509.Certificate, key crypto.Signer) error {
+	certPublicKey, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	keyPublicKey, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(certPublicKey, keyPublicKey) {
+		return fmt.Errorf("the public keys are different")
+	}
+	return nil
+}
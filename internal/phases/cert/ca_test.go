@@ -0,0 +1,160 @@
+package cert
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/pkg/pki"
+)
+
+func writeTestFile(t *testing.T, dir, file string, data []byte) {
+	path := filepath.Join(dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestCA(t *testing.T, dir string, withKey bool) {
+	cert, key, err := pki.NewCertificateAuthority(&pki.CertConfig{
+		Config:             certutil.Config{CommonName: "kubernetes"},
+		NotAfterTime:       time.Hour,
+		PublicKeyAlgorithm: x509.ECDSA,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "ca.crt", pki.EncodeCertPEM(cert))
+	if !withKey {
+		return
+	}
+	encodedKey, err := pki.EncodePrivateKeyPEM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "ca.key", encodedKey)
+}
+
+func TestLoadCAs(t *testing.T) {
+	externalFiles := externalCAFiles(&rundata.CertRootCA)
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, dir string)
+		wantCAs int
+		wantErr string
+	}{
+		{
+			name:    "no CA",
+			setup:   func(t *testing.T, dir string) {},
+			wantErr: "The CA ca is not found",
+		},
+		{
+			name: "CA with its key",
+			setup: func(t *testing.T, dir string) {
+				writeTestCA(t, dir, true)
+			},
+			wantCAs: 1,
+		},
+		{
+			name: "external CA without the files signed by it",
+			setup: func(t *testing.T, dir string) {
+				writeTestCA(t, dir, false)
+				writeTestFile(t, dir, "apiserver.crt", nil)
+			},
+			wantErr: ": apiserver.key, apiserver-kubelet-client.crt, apiserver-kubelet-client.key, controller-manager.conf, scheduler.conf, admin.conf, kubelet.conf",
+		},
+		{
+			name: "external CA with the files signed by it",
+			setup: func(t *testing.T, dir string) {
+				writeTestCA(t, dir, false)
+				for _, file := range externalFiles {
+					writeTestFile(t, dir, file, nil)
+				}
+			},
+			wantCAs: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "kubei-ca-dir")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			tt.setup(t, dir)
+
+			cas, err := LoadCAs(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadCAs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadCAs() error = %v", err)
+			}
+			if len(cas) != tt.wantCAs {
+				t.Errorf("LoadCAs() got %d CAs, want %d", len(cas), tt.wantCAs)
+			}
+		})
+	}
+}
+
+func TestExternalCAFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		ca   rundata.Cert
+		want []string
+	}{
+		{
+			name: "root CA",
+			ca:   rundata.CertRootCA,
+			want: []string{"apiserver.crt", "apiserver.key", "apiserver-kubelet-client.crt", "apiserver-kubelet-client.key",
+				"controller-manager.conf", "scheduler.conf", "admin.conf", "kubelet.conf"},
+		},
+		{
+			name: "front-proxy CA",
+			ca:   rundata.CertFrontProxyCA,
+			want: []string{"front-proxy-client.crt", "front-proxy-client.key"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := externalCAFiles(&tt.ca)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("externalCAFiles() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadServiceAccountKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubei-ca-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, dir, "sa.key", []byte("key"))
+	sa, err := LoadServiceAccountKey(dir)
+	if err != nil || len(sa.Key) != 0 || len(sa.PublicKey) != 0 {
+		t.Errorf("LoadServiceAccountKey() got = %+v, error = %v, want an empty key pair without sa.pub", sa, err)
+	}
+
+	writeTestFile(t, dir, "sa.pub", []byte("pub"))
+	sa, err = LoadServiceAccountKey(dir)
+	if err != nil || string(sa.Key) != "key" || string(sa.PublicKey) != "pub" {
+		t.Errorf("LoadServiceAccountKey() got = %+v, error = %v, want the key pair", sa, err)
+	}
+}
@@ -23,6 +23,15 @@ func CreateCert(c *rundata.Cluster) error {
 	color.HiBlue("Creating certificates for kubernetes and etcd 📘")
 
 	certTree := rundata.CertificateTree{}
+	if c.CADir != "" {
+		cas, err := LoadCAs(c.CADir)
+		if err != nil {
+			return err
+		}
+		for _, ca := range cas {
+			certTree[ca] = rundata.Certificates{}
+		}
+	}
 
 	certNotAfterTime := constants.Year * time.Duration(c.CertNotAfterTime)
 
@@ -33,6 +42,9 @@ func CreateCert(c *rundata.Cluster) error {
 		if err := CreatePKIAssets(node, &c.Kubeadm.InitConfiguration, certNotAfterTime, certTree); err != nil {
 			return err
 		}
+		if err := verifyCertTreeSANs(node, &c.Kubeadm.InitConfiguration); err != nil {
+			return err
+		}
 		certTree = node.CertificateTree
 
 		return node.CertificateTree.CreateKubeConfig(&c.Kubeadm.InitConfiguration)
@@ -43,13 +55,16 @@ func CreateCert(c *rundata.Cluster) error {
 
 	return c.RunOnOtherMasters(func(node *rundata.Node) error {
 		klog.V(2).Infof("[%s] [cert] Creating certificate", node.HostInfo.Host)
-		klog.V(3).Infof("[%s] [cert] The cert not after time is %v", node.HostInfo.Host)
+		klog.V(3).Infof("[%s] [cert] The cert not after time is %v", node.HostInfo.Host, certNotAfterTime)
 		//c.Mutex.Lock()
 		//c.Kubeadm.NodeRegistration.Name = node.Name
 
 		if err := CreatePKIAssets(node, &c.Kubeadm.InitConfiguration, certNotAfterTime, certTree); err != nil {
 			return err
 		}
+		if err := verifyCertTreeSANs(node, &c.Kubeadm.InitConfiguration); err != nil {
+			return err
+		}
 		//c.Mutex.Unlock()
 
 		return node.CertificateTree.CreateKubeConfig(&c.Kubeadm.InitConfiguration)
@@ -57,6 +72,31 @@ func CreateCert(c *rundata.Cluster) error {
 	})
 }
 
+// CreateCertWithCAs creates the certificates of the masters with the existing CAs of the cluster,
+// it is used by the masters joining the cluster.
+func CreateCertWithCAs(c *rundata.Cluster, cas rundata.Certificates) error {
+	color.HiBlue("Creating certificates with the CAs of the cluster 📘")
+
+	certTree := rundata.CertificateTree{}
+	for _, ca := range cas {
+		certTree[ca] = rundata.Certificates{}
+	}
+
+	certNotAfterTime := constants.Year * time.Duration(c.CertNotAfterTime)
+
+	return c.RunOnMasters(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [cert] Creating certificate", node.HostInfo.Host)
+		if err := CreatePKIAssets(node, &c.Kubeadm.InitConfiguration, certNotAfterTime, certTree); err != nil {
+			return err
+		}
+		if err := verifyCertTreeSANs(node, &c.Kubeadm.InitConfiguration); err != nil {
+			return err
+		}
+
+		return node.CertificateTree.CreateKubeConfig(&c.Kubeadm.InitConfiguration)
+	})
+}
+
 // CreatePKIAssets will create all PKI assets necessary.
 func CreatePKIAssets(node *rundata.Node, cfg *kubeadmapi.InitConfiguration, notAfterTime time.Duration, certTree rundata.CertificateTree) error {
 	klog.V(3).Infoln("creating PKI assets")
@@ -95,7 +135,7 @@ func CreateServiceAccountKeyAndPublicKey(keyType x509.PublicKeyAlgorithm) (crypt
 		return nil, nil, err
 	}
 
-	klog.V(3).Infoln("[certs] Generating %q key and public key", kubeadmconstants.ServiceAccountKeyBaseName)
+	klog.V(3).Infof("[certs] Generating %q key and public key", kubeadmconstants.ServiceAccountKeyBaseName)
 
 	return key, key.Public(), nil
 }
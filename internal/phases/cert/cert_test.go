@@ -1,13 +1,18 @@
 package cert
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/md5"
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/hex"
 	"fmt"
 	"github.com/yuyicai/kubei/internal/rundata"
 	"github.com/yuyicai/kubei/pkg/pki"
 	kubeadmapi "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm"
+	"k8s.io/kubernetes/cmd/kubeadm/app/features"
 	"strconv"
 	"strings"
 	"sync"
@@ -34,6 +39,10 @@ func TestCreateServiceAccountKeyAndPublicKeyFiles(t *testing.T) {
 			name: "SA",
 			args: args{keyType: x509.RSA},
 		},
+		{
+			name: "SA, ECDSA",
+			args: args{keyType: x509.ECDSA},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -42,6 +51,9 @@ func TestCreateServiceAccountKeyAndPublicKeyFiles(t *testing.T) {
 				t.Errorf("CreateServiceAccountKeyAndPublicKey() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if keyType := publicKeyAlgorithm(got1); keyType != tt.args.keyType {
+				t.Errorf("CreateServiceAccountKeyAndPublicKey() key type = %v, want %v", keyType, tt.args.keyType)
+			}
 			key, _ := pki.EncodePrivateKeyPEM(got)
 			publicKey, _ := pki.EncodePublicKeyPEM(got1)
 			t.Log(string(key))
@@ -175,6 +187,7 @@ func TestCreatePKIAssets(t *testing.T) {
 
 			nodes[0] = &rundata.Node{}
 			nodes[0].Name = "yyzz"
+			nodes[0].HostInfo.Host = "172.16.0.111"
 
 			if err := CreatePKIAssets(nodes[0], tt.args.cfg, tt.args.notAfterTime, certTree); (err != nil) != tt.wantErr {
 				t.Errorf("CreatePKIAssets() error = %v, wantErr %v", err, tt.wantErr)
@@ -197,6 +210,64 @@ func TestCreatePKIAssets(t *testing.T) {
 	}
 }
 
+func TestCreatePKIAssetsKeyAlgorithm(t *testing.T) {
+	tests := []struct {
+		name         string
+		featureGates map[string]bool
+		want         x509.PublicKeyAlgorithm
+	}{
+		{
+			name: "RSA",
+			want: x509.RSA,
+		},
+		{
+			name:         "ECDSA",
+			featureGates: map[string]bool{features.PublicKeysECDSA: true},
+			want:         x509.ECDSA,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := SetCfg()
+			cfg.LocalAPIEndpoint.AdvertiseAddress = "172.16.0.111"
+			cfg.Networking.ServiceSubnet = "10.96.0.0/12"
+			cfg.NodeRegistration.Name = "test"
+			cfg.FeatureGates = tt.featureGates
+
+			node := &rundata.Node{}
+			node.Name = "test"
+			node.HostInfo.Host = "172.16.0.111"
+
+			if err := CreatePKIAssets(node, cfg, 24*time.Hour*365, rundata.CertificateTree{}); err != nil {
+				t.Fatalf("CreatePKIAssets() error = %v", err)
+			}
+
+			for ca, certs := range node.CertificateTree {
+				for _, cert := range append(certs, ca) {
+					if cert.Cert.PublicKeyAlgorithm != tt.want {
+						t.Errorf("certificate %s public key algorithm = %v, want %v", cert.Name, cert.Cert.PublicKeyAlgorithm, tt.want)
+					}
+					if keyType := publicKeyAlgorithm(cert.Key.Public()); keyType != tt.want {
+						t.Errorf("key of %s type = %v, want %v", cert.Name, keyType, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func publicKeyAlgorithm(key crypto.PublicKey) x509.PublicKeyAlgorithm {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return x509.RSA
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return x509.ECDSA
+		}
+	}
+	return x509.UnknownPublicKeyAlgorithm
+}
+
 func TestCreatePKIAssetsLock(t *testing.T) {
 	type args struct {
 		cfg          *kubeadmapi.InitConfiguration
@@ -259,6 +330,7 @@ func TestCreatePKIAssetsLock(t *testing.T) {
 					fmt.Println("---------------------------------------------")
 					n = &rundata.Node{}
 					n.Name = "yyzz" + strconv.Itoa(i)
+					n.HostInfo.Host = "172.16.0." + strconv.Itoa(i+1)
 
 					if err := CreatePKIAssets(n, tt.args.cfg, tt.args.notAfterTime, certTree); (err != nil) != tt.wantErr {
 						t.Errorf("CreatePKIAssets() error = %v, wantErr %v", err, tt.wantErr)
@@ -0,0 +1,161 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"path"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+)
+
+// Expiration is the expiration of a certificate on a master.
+type Expiration struct {
+	Node   string
+	Name   string
+	CAName string
+	// NotAfter is zero if the certificate is not found on the master
+	NotAfter time.Time
+}
+
+// CheckExpiration reads the certificates and the kubeconfig files created by kubei on the masters,
+// the expirations are returned in the order of the masters and of rundata.GetDefaultCertList.
+func CheckExpiration(c *rundata.Cluster) ([]Expiration, error) {
+	nodeExpirations := map[*rundata.Node][]Expiration{}
+	if err := c.RunOnMasters(func(node *rundata.Node) error {
+		var expirations []Expiration
+		for _, cert := range rundata.GetDefaultCertList() {
+			crt, err := loadCert(node, cert)
+			if err != nil {
+				return fmt.Errorf("[%s] [cert] Failed to load the certificate %s: %v", node.HostInfo.Host, cert.Name, err)
+			}
+
+			expiration := Expiration{Node: node.Name, Name: cert.Name, CAName: cert.CAName}
+			if crt != nil {
+				expiration.NotAfter = crt.NotAfter
+			}
+			expirations = append(expirations, expiration)
+		}
+
+		c.Mutex.Lock()
+		defer c.Mutex.Unlock()
+		nodeExpirations[node] = expirations
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var expirations []Expiration
+	for _, node := range c.ClusterNodes.Masters {
+		expirations = append(expirations, nodeExpirations[node]...)
+	}
+	return expirations, nil
+}
+
+// PrintExpiration prints the expirations as a table.
+func PrintExpiration(out io.Writer, expirations []Expiration) error {
+	w := tabwriter.NewWriter(out, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "NODE\tCERTIFICATE\tEXPIRES\tRESIDUAL TIME\tCERTIFICATE AUTHORITY")
+	for _, e := range expirations {
+		expires, residual := "<not found>", ""
+		if !e.NotAfter.IsZero() {
+			expires = e.NotAfter.UTC().Format("Jan 02, 2006 15:04 MST")
+			residual = residualTime(time.Until(e.NotAfter))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Node, e.Name, expires, residual, e.CAName)
+	}
+	return w.Flush()
+}
+
+func residualTime(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "<expired>"
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// certFile returns the path of the certificate on the masters, the kubeconfig files hold their certificates.
+func certFile(cert *rundata.Cert) string {
+	if cert.IsKubeConfig {
+		return path.Join("/etc/kubernetes", cert.BaseName)
+	}
+	return path.Join("/etc/kubernetes/pki", cert.BaseName+".crt")
+}
+
+func keyFile(cert *rundata.Cert) string {
+	if cert.IsKubeConfig {
+		return path.Join("/etc/kubernetes", cert.BaseName)
+	}
+	return path.Join("/etc/kubernetes/pki", cert.BaseName+".key")
+}
+
+// loadCert loads the certificate from the master, it is nil if the certificate is not found or in dry run mode.
+func loadCert(node *rundata.Node, cert *rundata.Cert) (*x509.Certificate, error) {
+	data, err := node.RunOut(tmpl.CatFile(certFile(cert)))
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+
+	if cert.IsKubeConfig {
+		if data, err = kubeConfigAuthInfo(data, func(a *clientcmdapiv1.AuthInfo) []byte { return a.ClientCertificateData }); err != nil || data == nil {
+			return nil, err
+		}
+	}
+
+	certs, err := certutil.ParseCertsPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+// loadKey loads the key from the master, it is nil if the key is not found or in dry run mode.
+func loadKey(node *rundata.Node, cert *rundata.Cert) (crypto.Signer, error) {
+	data, err := node.RunOut(tmpl.CatFile(keyFile(cert)))
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+
+	if cert.IsKubeConfig {
+		if data, err = kubeConfigAuthInfo(data, func(a *clientcmdapiv1.AuthInfo) []byte { return a.ClientKeyData }); err != nil || data == nil {
+			return nil, err
+		}
+	}
+
+	key, err := keyutil.ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.Errorf("the key of %s is not a signer", cert.Name)
+	}
+	return signer, nil
+}
+
+// kubeConfigAuthInfo returns the embedded data of the first user of the kubeconfig,
+// it is nil if the data is not embedded, e.g. the client certificate of the kubelet is rotated to a file.
+func kubeConfigAuthInfo(data []byte, f func(*clientcmdapiv1.AuthInfo) []byte) ([]byte, error) {
+	config := clientcmdapiv1.Config{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if len(config.AuthInfos) == 0 {
+		return nil, nil
+	}
+	return f(&config.AuthInfos[0].AuthInfo), nil
+}
@@ -0,0 +1,186 @@
+package cert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"k8s.io/klog"
+	kubeadmapi "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm"
+
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+	"github.com/yuyicai/kubei/pkg/pki"
+)
+
+// RenewAll is the name to renew all the certificates.
+const RenewAll = "all"
+
+// RenewableCertNames returns the names of the certificates that can be renewed, the CAs are never renewed.
+func RenewableCertNames() []string {
+	var names []string
+	for _, cert := range rundata.GetDefaultCertList() {
+		if cert.CAName != "" {
+			names = append(names, cert.Name)
+		}
+	}
+	return names
+}
+
+// ValidateRenewName checks that the name is "all" or the name of a certificate that can be renewed.
+func ValidateRenewName(name string) error {
+	names := RenewableCertNames()
+	if name == RenewAll {
+		return nil
+	}
+	for _, n := range names {
+		if n == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown certificate %q, it must be %s or one of %s", name, RenewAll, strings.Join(names, ", "))
+}
+
+// RenewCerts re-signs the certificates of the masters with the CAs of the first master, the keys of the certificates are kept.
+// The certificates are pushed and the control plane static Pods are restarted one master at a time.
+func RenewCerts(c *rundata.Cluster, name string) error {
+	ic := &c.Kubeadm.InitConfiguration
+	certNotAfterTime := constants.Year * time.Duration(c.CertNotAfterTime)
+
+	var cas rundata.CertificateMap
+	if err := c.RunOnFirstMaster(func(node *rundata.Node) error {
+		var err error
+		cas, err = loadCAs(node, ic)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	color.HiBlue("Renewing certificates 📘")
+	renew := func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [cert] Renewing certificate %s", node.HostInfo.Host, name)
+		klog.V(3).Infof("[%s] [cert] The cert not after time is %v", node.HostInfo.Host, certNotAfterTime)
+		certs, err := renewCerts(node, ic, cas, name, certNotAfterTime)
+		if err != nil {
+			return fmt.Errorf("[%s] [cert] Failed to renew certificate %s: %v", node.HostInfo.Host, name, err)
+		}
+
+		if err := sendRenewedCerts(node, certs); err != nil {
+			return fmt.Errorf("[%s] [cert] Failed to send the renewed certificates: %v", node.HostInfo.Host, err)
+		}
+
+		klog.V(2).Infof("[%s] [cert] Restarting the control plane static Pods", node.HostInfo.Host)
+		if err := node.Run(tmpl.RestartControlPlane()); err != nil {
+			return fmt.Errorf("[%s] [cert] Failed to restart the control plane static Pods: %v", node.HostInfo.Host, err)
+		}
+		if err := node.Run(tmpl.WaitAPIServerHealthy(ic.LocalAPIEndpoint.BindPort, int(constants.DefaultWaitAPIServerTimeout.Seconds()))); err != nil {
+			return fmt.Errorf("[%s] [cert] The apiserver is not healthy after the restart: %v", node.HostInfo.Host, err)
+		}
+
+		fmt.Printf("[%s] [cert] renew certificate %s: %s\n", node.HostInfo.Host, name, color.HiGreenString("done✅️"))
+		return nil
+	}
+
+	if err := c.RunOnFirstMaster(renew); err != nil {
+		return err
+	}
+	return c.RunOnOtherMastersOneByOne(renew)
+}
+
+// loadCAs loads the CAs from the master. In dry run mode the files are not read, so temporary CAs are created
+// to show the scripts.
+func loadCAs(node *rundata.Node, ic *kubeadmapi.InitConfiguration) (rundata.CertificateMap, error) {
+	cas := rundata.CertificateMap{}
+	for _, ca := range []rundata.Cert{rundata.CertRootCA, rundata.CertFrontProxyCA, rundata.CertEtcdCA} {
+		ca := ca
+		crt, err := loadCert(node, &ca)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] [cert] Failed to load the CA %s: %v", node.HostInfo.Host, ca.Name, err)
+		}
+		key, err := loadKey(node, &ca)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] [cert] Failed to load the key of the CA %s: %v", node.HostInfo.Host, ca.Name, err)
+		}
+
+		switch {
+		case crt != nil && key != nil:
+			ca.Cert, ca.Key = crt, key
+		case node.IsDryRun():
+			ca.Config.NotAfterTime = constants.DefaultCertNotAfterTime
+			if err := ca.CreateAsCA(node, ic); err != nil {
+				return nil, err
+			}
+		case crt != nil:
+			return nil, fmt.Errorf("[%s] [cert] The key of the CA %s is not found, the certificates of an external CA can not be renewed by kubei", node.HostInfo.Host, ca.Name)
+		default:
+			return nil, fmt.Errorf("[%s] [cert] The CA %s is not found, the certificates can not be renewed", node.HostInfo.Host, ca.Name)
+		}
+		cas[ca.Name] = &ca
+	}
+	return cas, nil
+}
+
+// renewCerts signs the certificates of the name with the existing keys, a new key is created if there is none.
+func renewCerts(node *rundata.Node, ic *kubeadmapi.InitConfiguration, cas rundata.CertificateMap, name string, notAfterTime time.Duration) (rundata.Certificates, error) {
+	var certs rundata.Certificates
+	for _, cert := range rundata.GetDefaultCertList() {
+		if cert.CAName == "" || (name != RenewAll && cert.Name != name) {
+			continue
+		}
+		ca := cas[cert.CAName]
+
+		key, err := loadKey(node, cert)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := cert.GetConfig(node, ic)
+		if err != nil {
+			return nil, err
+		}
+		cfg.NotAfterTime = notAfterTime
+
+		if key == nil {
+			klog.V(3).Infof("[%s] [cert] The key of %s is not found, creating a new key", node.HostInfo.Host, cert.Name)
+			cert.Cert, cert.Key, err = pki.NewCertAndKey(ca.Cert, ca.Key, cfg)
+		} else {
+			cert.Key = key
+			cert.Cert, err = pki.NewSignedCert(cfg, key, ca.Cert, ca.Key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyCertSANs(node, ic, cert); err != nil {
+			return nil, err
+		}
+
+		if err := cert.CreateKubeConfig(ic, ca.Cert); err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+func sendRenewedCerts(node *rundata.Node, certs rundata.Certificates) error {
+	for _, cert := range certs {
+		if !cert.IsKubeConfig {
+			if err := sendCert(node, cert); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := sendKubeConfig(node, cert); err != nil {
+			return err
+		}
+		if cert.Name == rundata.CertAPIServerAdminClient.Name {
+			if err := node.Run(tmpl.CopyAdminConfig()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
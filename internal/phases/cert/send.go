@@ -1,34 +1,45 @@
 package cert
 
 import (
-	"crypto/x509"
-	"encoding/base64"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/fatih/color"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
+	kubeadmconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
 
 	"github.com/yuyicai/kubei/internal/rundata"
 	"github.com/yuyicai/kubei/pkg/pki"
 )
 
+const (
+	// secretFileMode is the mode of the keys and the kubeconfig files, the same as kubeadm
+	secretFileMode = 0600
+	publicFileMode = 0644
+)
+
+// SendCert sends the certificates to the masters, the service account key pair is created if the cluster has none.
 func SendCert(c *rundata.Cluster) error {
-	encodedPrivatKey, encodedPublicKey, err := CreateEncodeServiceAccountKeyAndPublicKey(x509.RSA)
-	if err != nil {
-		return err
+	if len(c.ServiceAccountKey.Key) == 0 {
+		encodedPrivatKey, encodedPublicKey, err := CreateEncodeServiceAccountKeyAndPublicKey(c.Kubeadm.PublicKeyAlgorithm())
+		if err != nil {
+			return err
+		}
+		c.ServiceAccountKey = rundata.KeyPair{Key: encodedPrivatKey, PublicKey: encodedPublicKey}
 	}
 
-	encodedPrivatKeyBase64 := base64.StdEncoding.EncodeToString(encodedPrivatKey)
-	encodedPublicKeyBase64 := base64.StdEncoding.EncodeToString(encodedPublicKey)
+	encodedPrivatKey, encodedPublicKey := c.ServiceAccountKey.Key, c.ServiceAccountKey.PublicKey
 
 	return c.RunOnMasters(func(node *rundata.Node) error {
-		if err := sendServiceAccountKeyAndPublicKey(node, encodedPrivatKeyBase64, encodedPublicKeyBase64); err != nil {
+		if err := sendServiceAccountKeyAndPublicKey(node, encodedPrivatKey, encodedPublicKey); err != nil {
 			return err
 		}
 
-		if err := sendCertAndKubeConfig(node); err != nil {
+		if err := sendCertAndKubeConfig(node, c.CADir); err != nil {
 			return err
 		}
 
@@ -38,7 +49,23 @@ func SendCert(c *rundata.Cluster) error {
 
 }
 
-func sendCertAndKubeConfig(node *rundata.Node) error {
+// SendExternalKubeletConf sends the kubelet.conf of the CA directory to the first master if the root CA is an external CA,
+// kubeadm init needs it as it can not create it without the key of the CA. The masters joining the cluster must not have one.
+func SendExternalKubeletConf(c *rundata.Cluster) error {
+	if c.CADir == "" {
+		return nil
+	}
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		for ca := range node.CertificateTree {
+			if ca.Name == rundata.CertRootCA.Name && ca.Key == nil {
+				return sendExternalCAFile(node, c.CADir, kubeadmconstants.KubeletKubeConfigFileName)
+			}
+		}
+		return nil
+	})
+}
+
+func sendCertAndKubeConfig(node *rundata.Node, caDir string) error {
 
 	if err := node.Run("mkdir -p /etc/kubernetes/pki/etcd"); err != nil {
 		return err
@@ -52,6 +79,23 @@ func sendCertAndKubeConfig(node *rundata.Node) error {
 		}
 
 		for _, cert := range certs {
+			// the certs of an external CA are not created by kubei, they are sent from the CA directory
+			if cert.Cert == nil {
+				if ca.Key != nil {
+					continue
+				}
+				file := cert.BaseName
+				if !cert.IsKubeConfig {
+					file += ".crt"
+					if err := sendExternalCAFile(node, caDir, cert.BaseName+".key"); err != nil {
+						return err
+					}
+				}
+				if err := sendExternalCAFile(node, caDir, file); err != nil {
+					return err
+				}
+				continue
+			}
 			if cert.IsKubeConfig {
 				if err := sendKubeConfig(node, cert); err != nil {
 					return err
@@ -68,21 +112,37 @@ func sendCertAndKubeConfig(node *rundata.Node) error {
 }
 
 func sendCert(node *rundata.Node, c *rundata.Cert) error {
-	// TODO set umask
-	// send cert
-	encodeCert := pki.EncodeCertPEM(c.Cert)
-	encodeCertBase64 := base64.StdEncoding.EncodeToString(encodeCert)
-	if err := node.Run(fmt.Sprintf("echo %s | base64 -d > /etc/kubernetes/pki/%s.crt", encodeCertBase64, c.BaseName)); err != nil {
+	if err := node.SendData(fmt.Sprintf("/etc/kubernetes/pki/%s.crt", c.BaseName), pki.EncodeCertPEM(c.Cert), publicFileMode); err != nil {
 		return err
 	}
 
-	// send key
+	// send key, the key of an external CA is not known
+	if c.Key == nil {
+		return nil
+	}
 	encodedKey, err := pki.EncodePrivateKeyPEM(c.Key)
 	if err != nil {
 		return err
 	}
-	encodedKeyBase64 := base64.StdEncoding.EncodeToString(encodedKey)
-	return node.Run(fmt.Sprintf("echo %s | base64 -d > /etc/kubernetes/pki/%s.key", encodedKeyBase64, c.BaseName))
+	return node.SendData(fmt.Sprintf("/etc/kubernetes/pki/%s.key", c.BaseName), encodedKey, secretFileMode)
+}
+
+// sendExternalCAFile sends the file signed by an external CA from the CA directory,
+// the certificates and the keys to /etc/kubernetes/pki and the kubeconfig files to /etc/kubernetes.
+func sendExternalCAFile(node *rundata.Node, caDir, file string) error {
+	data, err := ioutil.ReadFile(filepath.Join(caDir, file))
+	if err != nil {
+		return fmt.Errorf("[%s] [cert] Failed to read the file %s of the external CA: %v", node.HostInfo.Host, file, err)
+	}
+
+	dst, mode := filepath.Join(kubeadmconstants.KubernetesDir, "pki", file), os.FileMode(publicFileMode)
+	switch filepath.Ext(file) {
+	case ".key":
+		mode = secretFileMode
+	case ".conf":
+		dst, mode = filepath.Join(kubeadmconstants.KubernetesDir, file), secretFileMode
+	}
+	return node.SendData(dst, data, mode)
 }
 
 func sendKubeConfig(node *rundata.Node, c *rundata.Cert) error {
@@ -90,26 +150,18 @@ func sendKubeConfig(node *rundata.Node, c *rundata.Cert) error {
 	if err != nil {
 		return err
 	}
-	encodedKubeConfigBase64 := base64.StdEncoding.EncodeToString(encodedKubeConfig)
-
-	//if c.Name == "admin" {
-	//	if err := node.Run(fmt.Sprintf("mkdir -p $HOME/.kube && echo %s | base64 -d > $HOME/.kube/config", encodedKubeConfigBase64)); err != nil {
-	//		return err
-	//	}
-	//}
-
-	return node.Run(fmt.Sprintf("echo %s | base64 -d > /etc/kubernetes/%s", encodedKubeConfigBase64, c.BaseName))
+	return node.SendData(fmt.Sprintf("/etc/kubernetes/%s", c.BaseName), encodedKubeConfig, secretFileMode)
 }
 
-func sendServiceAccountKeyAndPublicKey(node *rundata.Node, privatKey, publicKey string) error {
+func sendServiceAccountKeyAndPublicKey(node *rundata.Node, privatKey, publicKey []byte) error {
 	if err := node.Run("mkdir -p /etc/kubernetes/pki/etcd"); err != nil {
 		return err
 	}
 
-	if err := node.Run(fmt.Sprintf("echo %s | base64 -d > /etc/kubernetes/pki/%s", privatKey, "sa.key")); err != nil {
+	if err := node.SendData("/etc/kubernetes/pki/sa.key", privatKey, secretFileMode); err != nil {
 		return err
 	}
-	return node.Run(fmt.Sprintf("echo %s | base64 -d > /etc/kubernetes/pki/%s", publicKey, "sa.pub"))
+	return node.SendData("/etc/kubernetes/pki/sa.pub", publicKey, publicFileMode)
 }
 
 // EncodeKubeConfig serializes the config to yaml.
@@ -0,0 +1,82 @@
+package cert
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	kubeadmapi "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm"
+	kubeadmutil "k8s.io/kubernetes/cmd/kubeadm/app/util"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+// verifyCertTreeSANs verifies the SANs of the certificates of the master, see verifyCertSANs.
+func verifyCertTreeSANs(node *rundata.Node, ic *kubeadmapi.InitConfiguration) error {
+	for _, certs := range node.CertificateTree {
+		for _, cert := range certs {
+			if err := verifyCertSANs(node, ic, cert); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// verifyCertSANs verifies that the apiserver certificate holds the node IP, the control plane endpoint and the extra SANs,
+// and that the etcd server certificate holds the node IP and the extra SANs. The other certificates are not verified.
+func verifyCertSANs(node *rundata.Node, ic *kubeadmapi.InitConfiguration, cert *rundata.Cert) error {
+	// the certificates of an external CA are not created by kubei
+	if cert.Cert == nil {
+		return nil
+	}
+
+	sans := []string{node.HostInfo.Host}
+	switch cert.Name {
+	case rundata.CertAPIServer.Name:
+		if ic.ControlPlaneEndpoint != "" {
+			host, _, err := kubeadmutil.ParseHostPort(ic.ControlPlaneEndpoint)
+			if err != nil {
+				return err
+			}
+			sans = append(sans, host)
+		}
+		sans = append(sans, ic.APIServer.CertSANs...)
+	case rundata.CertEtcdServer.Name:
+		if ic.Etcd.Local != nil {
+			sans = append(sans, ic.Etcd.Local.ServerCertSANs...)
+		}
+	default:
+		return nil
+	}
+
+	var missing []string
+	for _, san := range sans {
+		if !hasSAN(cert, san) {
+			missing = append(missing, san)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("[%s] [cert] The certificate %s does not hold the Subject Alternative Names %s",
+			node.HostInfo.Host, cert.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func hasSAN(cert *rundata.Cert, san string) bool {
+	if ip := net.ParseIP(san); ip != nil {
+		for _, certIP := range cert.Cert.IPAddresses {
+			if certIP.Equal(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, name := range cert.Cert.DNSNames {
+		if name == san {
+			return true
+		}
+	}
+	return false
+}
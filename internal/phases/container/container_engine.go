@@ -2,6 +2,9 @@ package container
 
 import (
 	"fmt"
+
+	kubeadmconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
+
 	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/internal/rundata"
 )
@@ -11,11 +14,17 @@ func InstallContainerEngine(c *rundata.Cluster) error {
 	case constants.ContainerEngineTypeDocker:
 		return InstallDocker(c)
 	case constants.ContainerEngineTypeContainerd:
-		//TODO
+		return InstallContainerd(c)
 	case constants.ContainerEngineTypeCRIO:
-		//TODO
+		return InstallCRIO(c)
 	default:
 		fmt.Println("Uninstall container Engine")
 	}
 	return nil
 }
+
+// clusterPauseImage returns the pause image of kubeadm, so the sandbox image of the CRI container engines
+// is pulled from the image repository of the cluster
+func clusterPauseImage(imageRepository string) string {
+	return fmt.Sprintf("%s/pause:%s", imageRepository, kubeadmconstants.PauseVersion)
+}
@@ -0,0 +1,62 @@
+package container
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"k8s.io/klog"
+
+	"github.com/yuyicai/kubei/internal/phases/system"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+)
+
+func InstallContainerd(c *rundata.Cluster) error {
+	config, err := tmpl.ContainerdConfig(c.ContainerEngine.Containerd, clusterPauseImage(c.Kubeadm.ImageRepository), c.ContainerEngine.Registry)
+	if err != nil {
+		return fmt.Errorf("[container-engine] Failed to render the configuration of containerd: %v", err)
+	}
+
+	color.HiBlue("Installing containerd on all nodes 📦")
+	return c.RunOnAllNodes(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [container-engine] Installing containerd", node.HostInfo.Host)
+		if err := installContainerd(node, c.ContainerEngine.Containerd, c.Proxy); err != nil {
+			return fmt.Errorf("[%s] [container-engine] Failed to install containerd: %v", node.HostInfo.Host, err)
+		}
+
+		// the configuration holds the credentials of the registries
+		if err := node.SendData("/etc/containerd/config.toml", []byte(config), secretFileMode); err != nil {
+			return fmt.Errorf("[%s] [container-engine] Failed to send the configuration of containerd: %v", node.HostInfo.Host, err)
+		}
+
+		if err := sendRegistryAuths(node, c.ContainerEngine.Type, c.ContainerEngine.Registry.Auths); err != nil {
+			return err
+		}
+
+		// containerd is always restarted to load its configuration
+		if _, err := sendProxyDropIn(node, "containerd", c.Proxy); err != nil {
+			return err
+		}
+
+		if err := system.Restart("containerd", node); err != nil {
+			return err
+		}
+		fmt.Printf("[%s] [container-engine] install containerd: %s\n", node.HostInfo.Host, color.HiGreenString("done✅️"))
+		return nil
+	})
+}
+
+func installContainerd(node *rundata.Node, d rundata.Containerd, p rundata.Proxy) error {
+	cmdTmpl := tmpl.NewContainerEngineText(node.PackageManagementType)
+	cmd, err := cmdTmpl.Containerd(node.InstallType, d)
+	if err != nil {
+		return err
+	}
+
+	env, err := tmpl.ProxyEnv(node.InstallType, p)
+	if err != nil {
+		return err
+	}
+
+	return node.Run(env + cmd)
+}
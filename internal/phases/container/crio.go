@@ -0,0 +1,52 @@
+package container
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"k8s.io/klog"
+
+	"github.com/yuyicai/kubei/internal/phases/system"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+)
+
+func InstallCRIO(c *rundata.Cluster) error {
+	color.HiBlue("Installing CRI-O on all nodes 📦")
+	return c.RunOnAllNodes(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [container-engine] Installing CRI-O", node.HostInfo.Host)
+		if err := installCRIO(node, c.ContainerEngine.CRIO, clusterPauseImage(c.Kubeadm.ImageRepository), c.ContainerEngine.Registry, c.Proxy); err != nil {
+			return fmt.Errorf("[%s] [container-engine] Failed to install CRI-O: %v", node.HostInfo.Host, err)
+		}
+
+		if err := sendRegistryAuths(node, c.ContainerEngine.Type, c.ContainerEngine.Registry.Auths); err != nil {
+			return err
+		}
+
+		// CRI-O is always restarted to load its configuration
+		if _, err := sendProxyDropIn(node, "crio", c.Proxy); err != nil {
+			return err
+		}
+
+		if err := system.Restart("crio", node); err != nil {
+			return err
+		}
+		fmt.Printf("[%s] [container-engine] install CRI-O: %s\n", node.HostInfo.Host, color.HiGreenString("done✅️"))
+		return nil
+	})
+}
+
+func installCRIO(node *rundata.Node, d rundata.CRIO, pauseImage string, r rundata.Registry, p rundata.Proxy) error {
+	cmdTmpl := tmpl.NewContainerEngineText(node.PackageManagementType)
+	cmd, err := cmdTmpl.CRIO(node.InstallType, d, pauseImage, r)
+	if err != nil {
+		return err
+	}
+
+	env, err := tmpl.ProxyEnv(node.InstallType, p)
+	if err != nil {
+		return err
+	}
+
+	return node.Run(env + cmd)
+}
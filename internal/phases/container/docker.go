@@ -2,10 +2,13 @@ package container
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/pmezard/go-difflib/difflib"
 	"k8s.io/klog"
 
+	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/internal/phases/system"
 	"github.com/yuyicai/kubei/internal/rundata"
 	"github.com/yuyicai/kubei/internal/tmpl"
@@ -16,24 +19,99 @@ func InstallDocker(c *rundata.Cluster) error {
 	color.HiBlue("Installing Docker on all nodes 🐳")
 	return c.RunOnAllNodes(func(node *rundata.Node) error {
 		klog.V(2).Infof("[%s] [container-engine] Installing Docker", node.HostInfo.Host)
-		if err := installDocker(node, c.ContainerEngine.Docker); err != nil {
+		if err := installDocker(node, c.ContainerEngine.Docker, c.Proxy); err != nil {
 			return fmt.Errorf("[%s] [container-engine] Failed to install Docker: %v", node.HostInfo.Host, err)
 		}
 
-		if err := system.Restart("docker", node); err != nil {
+		changed, err := configDocker(node, c.ContainerEngine.Docker, c.ContainerEngine.Registry)
+		if err != nil {
 			return err
 		}
+
+		proxyChanged, err := sendProxyDropIn(node, "docker", c.Proxy)
+		if err != nil {
+			return err
+		}
+
+		if err := sendRegistryAuths(node, c.ContainerEngine.Type, c.ContainerEngine.Registry.Auths); err != nil {
+			return err
+		}
+
+		if changed || proxyChanged {
+			if err := system.Restart("docker", node); err != nil {
+				return err
+			}
+		} else {
+			klog.V(2).Infof("[%s] [container-engine] The configuration of Docker is not changed, Docker is not restarted", node.HostInfo.Host)
+			if err := system.Start("docker", node); err != nil {
+				return err
+			}
+		}
 		fmt.Printf("[%s] [container-engine] install Docker: %s\n", node.HostInfo.Host, color.HiGreenString("done✅️"))
 		return nil
 	})
 }
 
-func installDocker(node *rundata.Node, d rundata.Docker) error {
+func installDocker(node *rundata.Node, d rundata.Docker, p rundata.Proxy) error {
 	cmdTmpl := tmpl.NewContainerEngineText(node.PackageManagementType)
 	cmd, err := cmdTmpl.Docker(node.InstallType, d)
 	if err != nil {
 		return err
 	}
 
-	return node.Run(cmd)
+	env, err := tmpl.ProxyEnv(node.InstallType, p)
+	if err != nil {
+		return err
+	}
+
+	return node.Run(env + cmd)
+}
+
+// configDocker merges the settings of kubei into the daemon.json of the node and reports whether the file is changed.
+// The changes are shown in dry run mode and with -v 2, the file is read in dry run mode too.
+func configDocker(node *rundata.Node, d rundata.Docker, r rundata.Registry) (bool, error) {
+	file := constants.DockerDaemonConfigFile
+	current, err := node.ReadFact(tmpl.CatFile(file))
+	if err != nil {
+		return false, fmt.Errorf("[%s] [container-engine] Failed to read %s: %v", node.HostInfo.Host, file, err)
+	}
+
+	cmdTmpl := tmpl.NewContainerEngineText(node.PackageManagementType)
+	data, changed, err := cmdTmpl.DockerDaemonConfig(current, d, r)
+	if err != nil {
+		return false, fmt.Errorf("[%s] [container-engine] Failed to merge %s: %v", node.HostInfo.Host, file, err)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if node.IsDryRun() || bool(klog.V(2)) {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(string(current)),
+			B:        splitLines(string(data)),
+			FromFile: file,
+			ToFile:   file,
+			Context:  3,
+		})
+		if err != nil {
+			return false, fmt.Errorf("[%s] [container-engine] Failed to diff %s: %v", node.HostInfo.Host, file, err)
+		}
+		fmt.Printf("[%s] [container-engine] Changes of %s:\n%s", node.HostInfo.Host, file, diff)
+	}
+
+	klog.V(2).Infof("[%s] [container-engine] Writing %s", node.HostInfo.Host, file)
+	if err := node.SendData(file, data, 0644); err != nil {
+		return false, fmt.Errorf("[%s] [container-engine] Failed to write %s: %v", node.HostInfo.Host, file, err)
+	}
+	return true, nil
+}
+
+// splitLines splits the text into lines ending with a newline, an empty text has no lines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(s, "\n"), "\n")
+	lines[len(lines)-1] += "\n"
+	return lines
 }
@@ -0,0 +1,40 @@
+package container
+
+import (
+	"fmt"
+
+	"k8s.io/klog"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+)
+
+// sendProxyDropIn sends the systemd drop-in of the proxy of the container engine and reports whether it is changed.
+// Nothing is sent if the proxy is not set, so a drop-in written by the user is kept.
+// The current drop-in is read in dry run mode too, so an unchanged drop-in does not restart the container engine.
+func sendProxyDropIn(node *rundata.Node, service string, p rundata.Proxy) (bool, error) {
+	if !p.IsSet() {
+		return false, nil
+	}
+
+	file := fmt.Sprintf("/etc/systemd/system/%s.service.d/http-proxy.conf", service)
+	dropIn, err := tmpl.ProxyDropIn(p)
+	if err != nil {
+		return false, fmt.Errorf("[%s] [container-engine] Failed to render %s: %v", node.HostInfo.Host, file, err)
+	}
+
+	current, err := node.ReadFact(tmpl.CatFile(file))
+	if err != nil {
+		return false, fmt.Errorf("[%s] [container-engine] Failed to read %s: %v", node.HostInfo.Host, file, err)
+	}
+	if string(current) == dropIn {
+		return false, nil
+	}
+
+	klog.V(2).Infof("[%s] [container-engine] Writing %s", node.HostInfo.Host, file)
+	// the proxy may hold a password
+	if err := node.SendData(file, []byte(dropIn), secretFileMode); err != nil {
+		return false, fmt.Errorf("[%s] [container-engine] Failed to write %s: %v", node.HostInfo.Host, file, err)
+	}
+	return true, nil
+}
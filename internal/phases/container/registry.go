@@ -0,0 +1,49 @@
+package container
+
+import (
+	"fmt"
+
+	"k8s.io/klog"
+
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+)
+
+// secretFileMode is the mode of the files holding the credentials of the registries
+const secretFileMode = 0600
+
+// sendRegistryAuths merges the credentials of the private registries into the credential file of the kubelet,
+// and of Docker if it is the container engine, the credentials already on the node are kept.
+// containerd reads them from its configuration, and CRI-O from the credential file of the kubelet.
+func sendRegistryAuths(node *rundata.Node, engineType string, auths []rundata.RegistryAuth) error {
+	if len(auths) == 0 {
+		return nil
+	}
+
+	klog.V(2).Infof("[%s] [container-engine] Sending the credentials of the registries", node.HostInfo.Host)
+	if engineType == constants.ContainerEngineTypeDocker {
+		if err := sendDockerConfig(node, constants.DockerCredentialFile, auths); err != nil {
+			return err
+		}
+	}
+	return sendDockerConfig(node, constants.KubeletCredentialFile, auths)
+}
+
+// sendDockerConfig reads the docker config file of the node, even in dry run mode, and writes it back with the credentials
+func sendDockerConfig(node *rundata.Node, file string, auths []rundata.RegistryAuth) error {
+	current, err := node.ReadFact(tmpl.CatFile(file))
+	if err != nil {
+		return fmt.Errorf("[%s] [container-engine] Failed to read %s: %v", node.HostInfo.Host, file, err)
+	}
+
+	data, err := tmpl.DockerConfig(current, auths)
+	if err != nil {
+		return fmt.Errorf("[%s] [container-engine] Failed to merge the credentials of the registries into %s: %v", node.HostInfo.Host, file, err)
+	}
+
+	if err := node.SendData(file, data, secretFileMode); err != nil {
+		return fmt.Errorf("[%s] [container-engine] Failed to write %s: %v", node.HostInfo.Host, file, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package dryrun
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/pkg/redact"
+)
+
+// Output prints the scripts recorded on each node in dry run mode, or writes them to a file per node.
+// The secrets and the PEM blocks of the scripts are masked.
+func Output(c *rundata.Cluster) error {
+	if !c.DryRun.Enabled {
+		return nil
+	}
+
+	if c.DryRun.Dir == "" {
+		color.HiBlue("Scripts that would run on the nodes, in order 📝")
+		for _, node := range c.ClusterNodes.GetAllNodes() {
+			fmt.Printf("\n%s\n", script(node))
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(c.DryRun.Dir, 0700); err != nil {
+		return fmt.Errorf("[dry-run] Failed to create directory %s: %v", c.DryRun.Dir, err)
+	}
+	for _, node := range c.ClusterNodes.GetAllNodes() {
+		file := filepath.Join(c.DryRun.Dir, node.Name+".sh")
+		if err := ioutil.WriteFile(file, []byte(script(node)), 0600); err != nil {
+			return fmt.Errorf("[dry-run] Failed to write %s: %v", file, err)
+		}
+		fmt.Printf("[%s] [dry-run] write scripts to %s: %s\n", node.HostInfo.Host, file, color.HiGreenString("done✅️"))
+	}
+	return nil
+}
+
+func script(node *rundata.Node) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!/bin/bash\n# node: %s (%s)\n", node.Name, node.HostInfo.Host)
+	if node.DryRun == nil {
+		return b.String()
+	}
+
+	for i, s := range node.DryRun.Scripts() {
+		fmt.Fprintf(&b, "\n# ---------- [%d] ----------\n%s\n", i+1, s)
+	}
+	return redact.String(b.String())
+}
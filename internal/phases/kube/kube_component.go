@@ -6,6 +6,7 @@ import (
 	"github.com/fatih/color"
 	"k8s.io/klog"
 
+	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/internal/phases/system"
 	"github.com/yuyicai/kubei/internal/rundata"
 	"github.com/yuyicai/kubei/internal/tmpl"
@@ -15,10 +16,17 @@ func InstallKubeComponent(c *rundata.Cluster) error {
 	color.HiBlue("Installing Kubernetes component ☸️")
 	return c.RunOnAllNodes(func(node *rundata.Node) error {
 		klog.V(2).Infof("[%s] [kube] Installing Kubernetes component", node.HostInfo.Host)
-		if err := installKubeComponent(c.Kubernetes.Version, node); err != nil {
+		if err := installKubeComponent(c.Kubernetes.Version, node, c.Proxy); err != nil {
 			return fmt.Errorf("[%s] [kube] Failed to install Kubernetes component: %v", node.HostInfo.Host, err)
 		}
 
+		if c.Kubeadm.ProxyMode == constants.ProxyModeIPVS {
+			klog.V(2).Infof("[%s] [kube] Installing ipset and ipvsadm", node.HostInfo.Host)
+			if err := installIPVS(node, c.Proxy); err != nil {
+				return fmt.Errorf("[%s] [kube] Failed to install ipset and ipvsadm: %v", node.HostInfo.Host, err)
+			}
+		}
+
 		if err := system.Restart("kubelet", node); err != nil {
 			return err
 		}
@@ -27,7 +35,7 @@ func InstallKubeComponent(c *rundata.Cluster) error {
 	})
 }
 
-func installKubeComponent(version string, node *rundata.Node) error {
+func installKubeComponent(version string, node *rundata.Node, p rundata.Proxy) error {
 
 	cmdTmpl := tmpl.NewKubeText(node.PackageManagementType)
 	cmd, err := cmdTmpl.KubeComponent(version, node.InstallType)
@@ -35,6 +43,26 @@ func installKubeComponent(version string, node *rundata.Node) error {
 		return err
 	}
 
-	return node.Run(cmd)
+	env, err := tmpl.ProxyEnv(node.InstallType, p)
+	if err != nil {
+		return err
+	}
+
+	return node.Run(env + cmd)
+
+}
+
+func installIPVS(node *rundata.Node, p rundata.Proxy) error {
+	cmdTmpl := tmpl.NewKubeText(node.PackageManagementType)
+	cmd, err := cmdTmpl.IPVS(node.InstallType)
+	if err != nil {
+		return err
+	}
+
+	env, err := tmpl.ProxyEnv(node.InstallType, p)
+	if err != nil {
+		return err
+	}
 
+	return node.Run(env + cmd)
 }
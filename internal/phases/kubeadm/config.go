@@ -0,0 +1,143 @@
+package kubeadm
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"path/filepath"
+
+	kubeadmapi "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm"
+	kubeadmapiv1beta2 "k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm/v1beta2"
+	kubeadmconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
+	"sigs.k8s.io/yaml"
+
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+const (
+	// ConfigFile is the kubeadm configuration file on the nodes, it holds the join data so it is removed after kubeadm runs
+	ConfigFile = "/etc/kubernetes/kubei-kubeadm.yaml"
+
+	kubeProxyAPIVersion = "kubeproxy.config.k8s.io/v1alpha1"
+	kubeProxyKind       = "KubeProxyConfiguration"
+	kubeletAPIVersion   = "kubelet.config.k8s.io/v1beta1"
+	kubeletKind         = "KubeletConfiguration"
+)
+
+// InitConfig renders the kubeadm v1beta2 InitConfiguration and ClusterConfiguration of the first master,
+// followed by the KubeProxyConfiguration and the KubeletConfiguration of the cluster if they are set.
+// The mode of the KubeProxyConfiguration is the configured proxy mode, it is rendered for the ipvs mode
+// even without other settings as kube-proxy defaults to iptables.
+func InitConfig(node *rundata.Node, kubernetes rundata.Kubernetes, kubeadmCfg rundata.Kubeadm) ([]byte, error) {
+	ic := kubeadmCfg.InitConfiguration.DeepCopy()
+	ic.NodeRegistration.Name = node.Name
+	ic.CertificateKey = kubernetes.Token.CertificateKey
+	if kubernetes.Version != "" {
+		ic.KubernetesVersion = "v" + kubernetes.Version
+	}
+	if net.ParseIP(ic.LocalAPIEndpoint.AdvertiseAddress) == nil {
+		// kubeadm detects the address when it is not an IP
+		ic.LocalAPIEndpoint.AdvertiseAddress = ""
+	}
+	if ic.CertificatesDir == "" {
+		ic.CertificatesDir = filepath.Join(kubeadmconstants.KubernetesDir, "pki")
+	}
+	if kubernetes.Token.Token != "" {
+		token, err := kubeadmapi.NewBootstrapTokenString(kubernetes.Token.Token)
+		if err != nil {
+			return nil, err
+		}
+		// the ttl, the usages and the groups of the token are defaulted by kubeadm
+		ic.BootstrapTokens = []kubeadmapi.BootstrapToken{{Token: token}}
+	}
+
+	initCfg := &kubeadmapiv1beta2.InitConfiguration{}
+	if err := kubeadmapiv1beta2.Convert_kubeadm_InitConfiguration_To_v1beta2_InitConfiguration(ic, initCfg, nil); err != nil {
+		return nil, err
+	}
+	initCfg.SetGroupVersionKind(kubeadmapiv1beta2.SchemeGroupVersion.WithKind("InitConfiguration"))
+
+	clusterCfg := &kubeadmapiv1beta2.ClusterConfiguration{}
+	if err := kubeadmapiv1beta2.Convert_kubeadm_ClusterConfiguration_To_v1beta2_ClusterConfiguration(&ic.ClusterConfiguration, clusterCfg, nil); err != nil {
+		return nil, err
+	}
+	clusterCfg.SetGroupVersionKind(kubeadmapiv1beta2.SchemeGroupVersion.WithKind("ClusterConfiguration"))
+
+	docs := []interface{}{initCfg, clusterCfg}
+	if len(kubeadmCfg.KubeProxy) > 0 || kubeadmCfg.ProxyMode == constants.ProxyModeIPVS {
+		kubeProxy := componentConfig(kubeadmCfg.KubeProxy, kubeProxyAPIVersion, kubeProxyKind)
+		if kubeadmCfg.ProxyMode != "" {
+			kubeProxy["mode"] = kubeadmCfg.ProxyMode
+		}
+		docs = append(docs, kubeProxy)
+	}
+	if len(kubeadmCfg.Kubelet) > 0 {
+		docs = append(docs, componentConfig(kubeadmCfg.Kubelet, kubeletAPIVersion, kubeletKind))
+	}
+	return marshalDocuments(docs...)
+}
+
+// JoinConfig renders the kubeadm v1beta2 JoinConfiguration of the node, the node joins the control plane if controlPlane is set.
+func JoinConfig(node *rundata.Node, kubernetes rundata.Kubernetes, kubeadmCfg rundata.Kubeadm, controlPlane bool) ([]byte, error) {
+	jc := &kubeadmapi.JoinConfiguration{
+		NodeRegistration: kubeadmapi.NodeRegistrationOptions{
+			Name:      node.Name,
+			CRISocket: kubeadmCfg.NodeRegistration.CRISocket,
+			// the local SLB of the workers is a static Pod
+			IgnorePreflightErrors: []string{"DirAvailable--etc-kubernetes-manifests"},
+		},
+		Discovery: kubeadmapi.Discovery{
+			BootstrapToken: &kubeadmapi.BootstrapTokenDiscovery{
+				Token:             kubernetes.Token.Token,
+				APIServerEndpoint: kubeadmCfg.ControlPlaneEndpoint,
+			},
+		},
+	}
+	if kubernetes.Token.CaCertHash != "" {
+		jc.Discovery.BootstrapToken.CACertHashes = []string{"sha256:" + kubernetes.Token.CaCertHash}
+	}
+
+	if controlPlane {
+		jc.NodeRegistration.IgnorePreflightErrors = nil
+		jc.ControlPlane = &kubeadmapi.JoinControlPlane{
+			LocalAPIEndpoint: kubeadmapi.APIEndpoint{BindPort: kubeadmCfg.LocalAPIEndpoint.BindPort},
+			CertificateKey:   kubernetes.Token.CertificateKey,
+		}
+		if net.ParseIP(node.HostInfo.Host) != nil {
+			jc.ControlPlane.LocalAPIEndpoint.AdvertiseAddress = node.HostInfo.Host
+		}
+	}
+
+	joinCfg := &kubeadmapiv1beta2.JoinConfiguration{}
+	if err := kubeadmapiv1beta2.Convert_kubeadm_JoinConfiguration_To_v1beta2_JoinConfiguration(jc, joinCfg, nil); err != nil {
+		return nil, err
+	}
+	joinCfg.SetGroupVersionKind(kubeadmapiv1beta2.SchemeGroupVersion.WithKind("JoinConfiguration"))
+	return marshalDocuments(joinCfg)
+}
+
+// componentConfig returns the configuration of a component with its apiVersion and kind,
+// the apiVersion and the kind set by the user are kept.
+func componentConfig(cfg map[string]interface{}, apiVersion, kind string) map[string]interface{} {
+	out := map[string]interface{}{"apiVersion": apiVersion, "kind": kind}
+	for k, v := range cfg {
+		out[k] = v
+	}
+	return out
+}
+
+func marshalDocuments(docs ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, doc := range docs {
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal the kubeadm configuration: %v", err)
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
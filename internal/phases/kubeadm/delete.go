@@ -0,0 +1,47 @@
+package kubeadm
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"k8s.io/klog"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+)
+
+// DrainNode cordons and drains the node through the first master.
+func DrainNode(c *rundata.Cluster, nodeName string) error {
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [drain] Draining node %s", node.HostInfo.Host, nodeName)
+		if err := node.Run(tmpl.DrainNode(nodeName)); err != nil {
+			return fmt.Errorf("[%s] [drain] Failed to drain node %s: %v", node.HostInfo.Host, nodeName, err)
+		}
+		fmt.Printf("[%s] [drain] drain node %s: %s\n", node.HostInfo.Host, nodeName, color.HiGreenString("done✅️"))
+		return nil
+	})
+}
+
+// RemoveEtcdMember removes the etcd member of the master through the first master.
+func RemoveEtcdMember(c *rundata.Cluster, nodeName string) error {
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [etcd] Removing the etcd member of %s", node.HostInfo.Host, nodeName)
+		if err := node.Run(tmpl.RemoveEtcdMember(node.Name, nodeName)); err != nil {
+			return fmt.Errorf("[%s] [etcd] Failed to remove the etcd member of %s: %v", node.HostInfo.Host, nodeName, err)
+		}
+		fmt.Printf("[%s] [etcd] remove the etcd member of %s: %s\n", node.HostInfo.Host, nodeName, color.HiGreenString("done✅️"))
+		return nil
+	})
+}
+
+// DeleteNode deletes the Node object through the first master.
+func DeleteNode(c *rundata.Cluster, nodeName string) error {
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [delete] Deleting node %s", node.HostInfo.Host, nodeName)
+		if err := node.Run(tmpl.DeleteNode(nodeName)); err != nil {
+			return fmt.Errorf("[%s] [delete] Failed to delete node %s: %v", node.HostInfo.Host, nodeName, err)
+		}
+		fmt.Printf("[%s] [delete] delete node %s: %s\n", node.HostInfo.Host, nodeName, color.HiGreenString("done✅️"))
+		return nil
+	})
+}
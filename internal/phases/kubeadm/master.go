@@ -12,6 +12,7 @@ import (
 	"github.com/yuyicai/kubei/internal/phases/system"
 	"github.com/yuyicai/kubei/internal/rundata"
 	"github.com/yuyicai/kubei/internal/tmpl"
+	"github.com/yuyicai/kubei/pkg/redact"
 )
 
 // InitMaster init master0
@@ -31,37 +32,62 @@ func InitMaster(c *rundata.Cluster) error {
 			return err
 		}
 
-		klog.V(3).Infof("[%s] [kubeadm-init] Initializing master0", node.HostInfo.Host)
+		if err := ipvsModules(node, c.Kubeadm.ProxyMode); err != nil {
+			return err
+		}
 
-		output, err := initMaster(node, *c.Kubei, *c.Kubeadm)
+		// the CA is created by kubeadm init if the cert phase is skipped, its pin is set after init then
+		caCert, err := readClusterCA(node)
 		if err != nil {
 			return err
 		}
+		klog.V(2).Infof("[%s] [token] Creating the bootstrap token and the certificate key", node.HostInfo.Host)
+		if c.Kubernetes.Token, err = NewToken(caCert); err != nil {
+			return fmt.Errorf("[%s] [token] Failed to create the join data: %v", node.HostInfo.Host, err)
+		}
 
-		if err := copyAdminConfig(node); err != nil {
+		if err := kubernetesVersion(c, node); err != nil {
 			return err
 		}
 
-		fmt.Printf("[%s] [kubeadm-init] init master0: %s\n", node.HostInfo.Host, color.HiGreenString("done✅️"))
+		klog.V(3).Infof("[%s] [kubeadm-init] Initializing master0", node.HostInfo.Host)
 
-		klog.V(2).Infof("[%s] [token] Getting token from master init output", node.HostInfo.Host)
-		getToken(string(output), &c.Kubernetes.Token)
+		if err := initMaster(node, *c.Kubei, *c.Kubeadm); err != nil {
+			return err
+		}
+
+		if caCert == nil {
+			if caCert, err = clusterCA(node); err != nil {
+				return err
+			}
+			c.Kubernetes.Token.CaCertHash = CACertHash(caCert)
+			redact.Add(c.Kubernetes.Token.CaCertHash)
+		}
+
+		if err := copyAdminConfig(node); err != nil {
+			return err
+		}
 
+		fmt.Printf("[%s] [kubeadm-init] init master0: %s\n", node.HostInfo.Host, color.HiGreenString("done✅️"))
 		return nil
 	})
 }
 
-func initMaster(node *rundata.Node, kubeiCfg rundata.Kubei, kubeadmCfg rundata.Kubeadm) ([]byte, error) {
-	text, err := tmpl.Kubeadm(tmpl.Init, node.Name, kubeiCfg.Kubernetes, kubeadmCfg)
+func initMaster(node *rundata.Node, kubeiCfg rundata.Kubei, kubeadmCfg rundata.Kubeadm) error {
+	cfg, err := InitConfig(node, kubeiCfg.Kubernetes, kubeadmCfg)
 	if err != nil {
-		return nil, fmt.Errorf("[%s] [kubeadm-init] Failed to Initialize master0: %v", node.HostInfo.Host, err)
+		return fmt.Errorf("[%s] [kubeadm-init] Failed to render the kubeadm configuration: %v", node.HostInfo.Host, err)
 	}
+	klog.V(6).Infof("[%s] [kubeadm-init] The kubeadm configuration: \n%s", node.HostInfo.Host, cfg)
 
-	output, err := node.RunOut(text)
-	if err != nil {
-		return nil, fmt.Errorf("[%s] [kubeadm-init] Failed to Initialize master0: %v", node.HostInfo.Host, err)
+	if err := node.SendData(ConfigFile, cfg, 0600); err != nil {
+		return fmt.Errorf("[%s] [kubeadm-init] Failed to send the kubeadm configuration: %v", node.HostInfo.Host, err)
 	}
-	return output, nil
+
+	if err := node.Run(tmpl.KubeadmInit(ConfigFile)); err != nil {
+		return fmt.Errorf("[%s] [kubeadm-init] Failed to Initialize master0: %v", node.HostInfo.Host, err)
+	}
+	return nil
 }
 
 // JoinControlPlane join masters to ControlPlane
@@ -80,6 +106,10 @@ func JoinControlPlane(c *rundata.Cluster) error {
 			return err
 		}
 
+		if err := ipvsModules(node, c.Kubeadm.ProxyMode); err != nil {
+			return err
+		}
+
 		klog.V(3).Infof("[%s] [kubeadm-join] Joining to masters", node.HostInfo.Host)
 		if err := joinControlPlane(node, *c.Kubei, *c.Kubeadm); err != nil {
 			return err
@@ -96,31 +126,97 @@ func JoinControlPlane(c *rundata.Cluster) error {
 }
 
 func joinControlPlane(node *rundata.Node, kubeiCfg rundata.Kubei, kubeadmCfg rundata.Kubeadm) error {
-	text, err := tmpl.Kubeadm(tmpl.JoinControlPlane, node.Name, kubeiCfg.Kubernetes, kubeadmCfg)
-	if err != nil {
+	if err := sendJoinConfig(node, kubeiCfg.Kubernetes, kubeadmCfg, true); err != nil {
 		return fmt.Errorf("[%s] [kubeadm-join] Failed to join master nodes: %v", node.HostInfo.Host, err)
 	}
 
-	if err := node.Run(text); err != nil {
+	if err := node.Run(tmpl.KubeadmJoin(ConfigFile)); err != nil {
 		return fmt.Errorf("[%s] [kubeadm-join] Failed to join master nodes: %v", node.HostInfo.Host, err)
 	}
 
 	return nil
 }
 
-//getToken get token from kubeadm init output
-func getToken(str string, token *rundata.Token) {
-	if strSlice := strings.Split(str, "--token "); len(strSlice) > 1 {
-		token.Token = strSlice[1][:23]
+// CreateToken creates a bootstrap token on the first master for the nodes joining the cluster,
+// the Kubernetes version of the cluster is got from the first master too if it is not set.
+func CreateToken(c *rundata.Cluster) error {
+	color.HiBlue("Creating bootstrap token 🔑")
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		caCert, err := clusterCA(node)
+		if err != nil {
+			return err
+		}
+		token, err := NewToken(caCert)
+		if err != nil {
+			return fmt.Errorf("[%s] [token] Failed to create bootstrap token: %v", node.HostInfo.Host, err)
+		}
+
+		klog.V(2).Infof("[%s] [token] Creating bootstrap token", node.HostInfo.Host)
+		if err := node.Run(tmpl.CreateToken(token.Token)); err != nil {
+			return fmt.Errorf("[%s] [token] Failed to create bootstrap token: %v", node.HostInfo.Host, err)
+		}
+		// the certificate key is set by UploadCerts when the certificates are uploaded
+		token.CertificateKey = ""
+		c.Kubernetes.Token = token
+
+		if err := kubernetesVersion(c, node); err != nil {
+			return err
+		}
+
+		fmt.Printf("[%s] [token] create bootstrap token: %s\n", node.HostInfo.Host, color.HiGreenString("done✅️"))
+		return nil
+	})
+}
+
+// UploadCerts uploads the certificates of the first master to the kubeadm-certs Secret for the masters joining the cluster,
+// the certificate key expires after two hours.
+func UploadCerts(c *rundata.Cluster) error {
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		certificateKey, err := NewCertificateKey()
+		if err != nil {
+			return fmt.Errorf("[%s] [upload-certs] Failed to upload the certificates: %v", node.HostInfo.Host, err)
+		}
+
+		klog.V(2).Infof("[%s] [upload-certs] Uploading the certificates", node.HostInfo.Host)
+		if err := node.Run(tmpl.UploadCerts(certificateKey)); err != nil {
+			return fmt.Errorf("[%s] [upload-certs] Failed to upload the certificates: %v", node.HostInfo.Host, err)
+		}
+		c.Kubernetes.Token.CertificateKey = certificateKey
+
+		fmt.Printf("[%s] [upload-certs] upload certificates: %s\n", node.HostInfo.Host, color.HiGreenString("done✅️"))
+		return nil
+	})
+}
+
+// kubernetesVersion sets the Kubernetes version from the kubeadm of the master if it is not set
+func kubernetesVersion(c *rundata.Cluster, node *rundata.Node) error {
+	if c.Kubernetes.Version != "" {
+		return nil
 	}
 
-	if strSlice := strings.Split(str, "sha256:"); len(strSlice) > 1 {
-		token.CaCertHash = strSlice[1][:64]
+	klog.V(2).Infof("[%s] [version] Getting the Kubernetes version of the cluster", node.HostInfo.Host)
+	output, err := node.ReadFact(tmpl.KubeadmVersion())
+	if err != nil {
+		// kubeadm is not installed on a new node in dry run mode
+		if node.IsDryRun() {
+			klog.V(2).Infof("[%s] [version] Failed to get the Kubernetes version of the cluster in dry run mode: %v", node.HostInfo.Host, err)
+			return nil
+		}
+		return fmt.Errorf("[%s] [version] Failed to get the Kubernetes version of the cluster: %v", node.HostInfo.Host, err)
 	}
+	c.Kubernetes.Version = strings.TrimPrefix(strings.TrimSpace(string(output)), "v")
+	klog.V(2).Infof("[%s] [version] The Kubernetes version of the cluster is %q", node.HostInfo.Host, c.Kubernetes.Version)
+	return nil
+}
 
-	if strSlice := strings.Split(str, "--certificate-key "); len(strSlice) > 1 {
-		token.CertificateKey = strSlice[1][:64]
+// sendJoinConfig sends the kubeadm configuration to join the node, it holds the join data so it is only readable by root
+func sendJoinConfig(node *rundata.Node, kubernetes rundata.Kubernetes, kubeadmCfg rundata.Kubeadm, controlPlane bool) error {
+	cfg, err := JoinConfig(node, kubernetes, kubeadmCfg, controlPlane)
+	if err != nil {
+		return fmt.Errorf("failed to render the kubeadm configuration: %v", err)
 	}
+	klog.V(6).Infof("[%s] [kubeadm-join] The kubeadm configuration: \n%s", node.HostInfo.Host, cfg)
+	return node.SendData(ConfigFile, cfg, 0600)
 }
 
 func copyAdminConfig(node *rundata.Node) error {
@@ -30,7 +30,11 @@ func JoinNode(c *rundata.Cluster) error {
 			return err
 		}
 
-		if err := ha(node, c.Kubei.ClusterNodes.GetAllMastersHost(), &c.Kubei.HA, c.Kubeadm); err != nil {
+		if err := ipvsModules(node, c.Kubeadm.ProxyMode); err != nil {
+			return err
+		}
+
+		if err := ha(node, c.Kubei.ClusterNodes.GetAllMastersHost(), &c.Kubei.HA, c.Kubeadm, c.ContainerEngine); err != nil {
 			return err
 		}
 
@@ -45,7 +49,40 @@ func JoinNode(c *rundata.Cluster) error {
 	}, color.HiBlueString("Joining to nodes ☸️"))
 }
 
-func ha(node *rundata.Node, masters []string, h *rundata.HA, kcfg *rundata.Kubeadm) error {
+// UpdateLocalSLB rewrites the upstream of the local SLB on the workers with all the masters of the cluster.
+func UpdateLocalSLB(c *rundata.Cluster) error {
+	if c.HA.Type != constants.HATypeLocalSLB {
+		return nil
+	}
+
+	return c.RunOnWorkersAndPrintLog(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [slb] Updating the upstream of the local SLB", node.HostInfo.Host)
+		if err := updateLocalSLB(node, c.ClusterNodes.GetAllMastersHost(), &c.HA.LocalSLB, c.Kubeadm, c.ContainerEngine); err != nil {
+			return fmt.Errorf("[%s] [slb] Failed to update the local SLB: %v", node.HostInfo.Host, err)
+		}
+		fmt.Printf("[%s] [slb] update the local SLB: %s\n", node.HostInfo.Host, color.HiGreenString("done✅️"))
+		return nil
+	}, color.HiBlueString("Updating the local SLB of the workers ⚖️"))
+}
+
+func updateLocalSLB(node *rundata.Node, masters []string, slb *rundata.LocalSLB, kcfg *rundata.Kubeadm, engine rundata.ContainerEngine) error {
+	switch slb.Type {
+	case constants.LocalSLBTypeNginx:
+		text, err := tmpl.NginxConf(masters, slb.Nginx.Port, strconv.FormatInt(int64(kcfg.LocalAPIEndpoint.BindPort), 10))
+		if err != nil {
+			return err
+		}
+		if err := node.Run(text); err != nil {
+			return err
+		}
+		return node.Run(tmpl.NginxReload(engine))
+	case constants.LocalSLBTypeHAproxy:
+		//TODO
+	}
+	return nil
+}
+
+func ha(node *rundata.Node, masters []string, h *rundata.HA, kcfg *rundata.Kubeadm, engine rundata.ContainerEngine) error {
 	apiDomainName, _, _ := net.SplitHostPort(kcfg.ControlPlaneEndpoint)
 
 	switch h.Type {
@@ -57,7 +94,7 @@ func ha(node *rundata.Node, masters []string, h *rundata.HA, kcfg *rundata.Kubea
 		}
 
 		klog.V(2).Infof("[%s] [slb] Setting up the local SLB", node.HostInfo.Host)
-		if err := localSLB(masters, node, &h.LocalSLB, kcfg); err != nil {
+		if err := localSLB(masters, node, &h.LocalSLB, kcfg, engine); err != nil {
 			return fmt.Errorf("[%s] Failed to set up the local SLB: %v", node.HostInfo.Host, err)
 		}
 		klog.V(1).Infof("[%s] [slb] Successfully set up the local SLB", node.HostInfo.Host)
@@ -69,17 +106,17 @@ func ha(node *rundata.Node, masters []string, h *rundata.HA, kcfg *rundata.Kubea
 	return nil
 }
 
-func localSLB(masters []string, node *rundata.Node, slb *rundata.LocalSLB, kubeadmCfg *rundata.Kubeadm) error {
+func localSLB(masters []string, node *rundata.Node, slb *rundata.LocalSLB, kubeadmCfg *rundata.Kubeadm, engine rundata.ContainerEngine) error {
 	switch slb.Type {
 	case constants.LocalSLBTypeNginx:
-		return nginx(node, &slb.Nginx, masters, kubeadmCfg)
+		return nginx(node, &slb.Nginx, masters, kubeadmCfg, engine)
 	case constants.LocalSLBTypeHAproxy:
 		//TODO
 	}
 	return nil
 }
 
-func nginx(node *rundata.Node, n *rundata.Nginx, masters []string, kcfg *rundata.Kubeadm) error {
+func nginx(node *rundata.Node, n *rundata.Nginx, masters []string, kcfg *rundata.Kubeadm, engine rundata.ContainerEngine) error {
 	text, err := tmpl.NginxConf(masters, n.Port, strconv.FormatInt(int64(kcfg.LocalAPIEndpoint.BindPort), 10))
 	if err != nil {
 		return err
@@ -92,7 +129,7 @@ func nginx(node *rundata.Node, n *rundata.Nginx, masters []string, kcfg *rundata
 		return err
 	}
 
-	if err := node.Run(tmpl.KubeletUnitFile(fmt.Sprintf("%s/%s", kcfg.ImageRepository, "pause:3.1"))); err != nil {
+	if err := node.Run(tmpl.KubeletUnitFile(fmt.Sprintf("%s/%s", kcfg.ImageRepository, "pause:3.1"), engine)); err != nil {
 		return err
 	}
 
@@ -115,6 +152,11 @@ func nginx(node *rundata.Node, n *rundata.Nginx, masters []string, kcfg *rundata
 }
 
 func checkHealth(node *rundata.Node, url string, interval, timeout time.Duration) error {
+	if node.IsDryRun() {
+		_, err := node.RunOut(fmt.Sprintf("curl -k %s", url))
+		return err
+	}
+
 	return wait.PollImmediate(interval, timeout, func() (done bool, err error) {
 		var output []byte
 		output, _ = node.RunOut(fmt.Sprintf("curl -k %s", url))
@@ -134,12 +176,24 @@ func iptables(node *rundata.Node) error {
 	return nil
 }
 
+// ipvsModules loads the kernel modules of the ipvs mode of kube-proxy, nothing is done for the other modes
+func ipvsModules(node *rundata.Node, proxyMode string) error {
+	if proxyMode != constants.ProxyModeIPVS {
+		return nil
+	}
+
+	klog.V(2).Infof("[%s] [ipvs] Loading the IPVS kernel modules", node.HostInfo.Host)
+	if err := node.Run(tmpl.IPVSModules()); err != nil {
+		return fmt.Errorf("[%s] [ipvs] Failed to load the IPVS kernel modules: %v", node.HostInfo.Host, err)
+	}
+	return nil
+}
+
 func joinNode(node *rundata.Node, kubeiCfg rundata.Kubei, kubeadmCfg rundata.Kubeadm) error {
-	text, err := tmpl.Kubeadm(tmpl.JoinNode, node.Name, kubeiCfg.Kubernetes, kubeadmCfg)
-	if err != nil {
+	if err := sendJoinConfig(node, kubeiCfg.Kubernetes, kubeadmCfg, false); err != nil {
 		return err
 	}
-	return node.Run(text)
+	return node.Run(tmpl.KubeadmJoin(ConfigFile))
 }
 
 func CheckNodesReady(c *rundata.Cluster) error {
@@ -167,6 +221,11 @@ func CheckNodesReady(c *rundata.Cluster) error {
 
 func checkNodesReady(node *rundata.Node, nodes []*rundata.Node, interval, timeout time.Duration) (string, error) {
 	var str string
+	if node.IsDryRun() {
+		_, err := node.RunOut("kubectl get nodes -owide")
+		return str, err
+	}
+
 	color.HiBlue("Waiting for all nodes to become ready. This can take up to %v⏳\n", timeout)
 	if err := wait.PollImmediate(interval, timeout, func() (done bool, err error) {
 		var output []byte
@@ -189,6 +248,11 @@ func checkNodesReady(node *rundata.Node, nodes []*rundata.Node, interval, timeou
 
 func checkNodesWithNotNetWorkPlugin(node *rundata.Node, nodes []*rundata.Node, interval, timeout time.Duration) (string, error) {
 	var str string
+	if node.IsDryRun() {
+		_, err := node.RunOut("kubectl get nodes -owide")
+		return str, err
+	}
+
 	color.HiBlue("Waiting for all nodes join to Kubernetes cluster. This can take up to %v⏳\n", timeout)
 	if err := wait.PollImmediate(interval, timeout, func() (done bool, err error) {
 		var output []byte
@@ -211,13 +275,13 @@ func LoadOfflineImages(c *rundata.Cluster) error {
 	g := errgroup.WithCancel(context.Background())
 	g.Go(func(ctx context.Context) error {
 		if err := c.RunOnMasters(func(node *rundata.Node) error {
-			return loadOfflineImagesOnnode("master", node)
+			return loadOfflineImagesOnnode("master", node, c.ContainerEngine)
 		}); err != nil {
 			return err
 		}
 
 		if err := c.RunOnAllNodes(func(node *rundata.Node) error {
-			return loadOfflineImagesOnnode("node", node)
+			return loadOfflineImagesOnnode("node", node, c.ContainerEngine)
 		}); err != nil {
 			return err
 		}
@@ -227,9 +291,9 @@ func LoadOfflineImages(c *rundata.Cluster) error {
 	return g.Wait()
 }
 
-func loadOfflineImagesOnnode(nodeType string, node *rundata.Node) error {
+func loadOfflineImagesOnnode(nodeType string, node *rundata.Node, engine rundata.ContainerEngine) error {
 	if node.InstallType == constants.InstallTypeOffline {
-		return node.Run(fmt.Sprintf("sh /tmp/.kubei/images/%s.sh", nodeType))
+		return node.Run(tmpl.LoadOfflineImages(nodeType, engine))
 	}
 	return nil
 }
@@ -0,0 +1,104 @@
+package kubeadm
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	certutil "k8s.io/client-go/util/cert"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+	"k8s.io/klog"
+	kubeadmconstants "k8s.io/kubernetes/cmd/kubeadm/app/constants"
+	"k8s.io/kubernetes/cmd/kubeadm/app/util/pubkeypin"
+
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+	"github.com/yuyicai/kubei/pkg/redact"
+)
+
+// certificateKeyBytes is the size of the AES-256 key that encrypts the certificates in the kubeadm-certs Secret
+const certificateKeyBytes = 32
+
+// NewToken creates the join data of the cluster without asking kubeadm: a bootstrap token, a certificate key
+// and the public key pin of the CA. The pin is empty if the CA is nil.
+func NewToken(caCert *x509.Certificate) (rundata.Token, error) {
+	token, err := bootstraputil.GenerateBootstrapToken()
+	if err != nil {
+		return rundata.Token{}, fmt.Errorf("failed to generate the bootstrap token: %v", err)
+	}
+
+	certificateKey, err := NewCertificateKey()
+	if err != nil {
+		return rundata.Token{}, err
+	}
+
+	t := rundata.Token{
+		Token:          token,
+		CaCertHash:     CACertHash(caCert),
+		CertificateKey: certificateKey,
+	}
+	redact.Add(t.Token, t.CaCertHash, t.CertificateKey)
+	return t, nil
+}
+
+// NewCertificateKey creates the hex encoded key for "kubeadm init phase upload-certs --certificate-key"
+func NewCertificateKey() (string, error) {
+	key := make([]byte, certificateKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate the certificate key: %v", err)
+	}
+	certificateKey := hex.EncodeToString(key)
+	redact.Add(certificateKey)
+	return certificateKey, nil
+}
+
+// CACertHash returns the public key pin of the CA for "--discovery-token-ca-cert-hash", without the "sha256:" prefix.
+// It is empty if the CA is nil.
+func CACertHash(caCert *x509.Certificate) string {
+	if caCert == nil {
+		return ""
+	}
+	return strings.TrimPrefix(pubkeypin.Hash(caCert), "sha256:")
+}
+
+// clusterCA returns the CA of the cluster. It may be missing only in dry run mode, where kubeadm init does not create it
+// if the cert phase did not run.
+func clusterCA(node *rundata.Node) (*x509.Certificate, error) {
+	caCert, err := readClusterCA(node)
+	if err != nil {
+		return nil, err
+	}
+	if caCert == nil && !node.IsDryRun() {
+		return nil, fmt.Errorf("[%s] [token] The CA of the cluster is not found", node.HostInfo.Host)
+	}
+	return caCert, nil
+}
+
+// readClusterCA returns the CA of the cluster. The CA created or loaded by the cert phase is used,
+// otherwise it is read from the master. It is nil if the master has no CA yet, e.g. before kubeadm init
+// creates it when the cert phase is skipped.
+func readClusterCA(node *rundata.Node) (*x509.Certificate, error) {
+	for ca := range node.CertificateTree {
+		if ca.Name == rundata.CertRootCA.Name && ca.Cert != nil {
+			return ca.Cert, nil
+		}
+	}
+
+	klog.V(2).Infof("[%s] [token] Reading the CA of the cluster", node.HostInfo.Host)
+	output, err := node.ReadFact(tmpl.CatFile(filepath.Join(kubeadmconstants.KubernetesDir, "pki", kubeadmconstants.CACertName)))
+	if err != nil {
+		return nil, fmt.Errorf("[%s] [token] Failed to read the CA of the cluster: %v", node.HostInfo.Host, err)
+	}
+	if len(output) == 0 {
+		return nil, nil
+	}
+
+	certs, err := certutil.ParseCertsPEM(output)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] [token] Failed to parse the CA of the cluster: %v", node.HostInfo.Host, err)
+	}
+	return certs[0], nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"k8s.io/klog"
 
+	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/internal/rundata"
 	"github.com/yuyicai/kubei/internal/tmpl"
 )
@@ -27,6 +28,10 @@ func resetkubeadmOnNode(node *rundata.Node, apiDomainName string) error {
 		return err
 	}
 
+	if err := node.Run(tmpl.ResetIPVS()); err != nil {
+		return err
+	}
+
 	return node.Run(tmpl.ResetHosts(apiDomainName))
 }
 
@@ -52,20 +57,26 @@ func removeKubeComponentOnNode(node *rundata.Node) error {
 
 func RemoveContainerEngine(c *rundata.Cluster) error {
 	return c.RunOnAllNodes(func(node *rundata.Node) error {
-		return removeContainerEngine(node)
+		return removeContainerEngine(node, c.ContainerEngine.Type)
 	})
 }
 
-func removeContainerEngine(node *rundata.Node) error {
+func removeContainerEngine(node *rundata.Node, engineType string) error {
 	klog.V(2).Infof("[%s] [remove] Remove container engine from the node", node.HostInfo.Host)
-	if err := removeContainerEngineOnNode(node); err != nil {
+	if err := removeContainerEngineOnNode(node, engineType); err != nil {
 		return fmt.Errorf("[%s] [remove] Failed to remove container engine: %v", node.HostInfo.Host, err)
 	}
 	klog.Infof("[%s] [remove] Successfully remove container engine", node.HostInfo.Host)
 	return nil
 }
 
-func removeContainerEngineOnNode(node *rundata.Node) error {
+func removeContainerEngineOnNode(node *rundata.Node, engineType string) error {
 	cmdTmpl := tmpl.NewContainerEngineText(node.PackageManagementType)
+	switch engineType {
+	case constants.ContainerEngineTypeContainerd:
+		return node.Run(cmdTmpl.RemoveContainerd())
+	case constants.ContainerEngineTypeCRIO:
+		return node.Run(cmdTmpl.RemoveCRIO())
+	}
 	return node.Run(cmdTmpl.RemoveDocker())
 }
@@ -44,7 +44,7 @@ func sendAndtar(dstFile, srcFile string, node *rundata.Node) error {
 }
 
 func sendFile(dstFile, srcFile string, node *rundata.Node) error {
-	return node.SSH.SendFile(dstFile, srcFile)
+	return node.SendFile(dstFile, srcFile)
 }
 
 func tar(file string, node *rundata.Node) error {
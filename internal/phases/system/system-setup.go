@@ -23,6 +23,14 @@ func SwapOff(node *rundata.Node) error {
 	return nil
 }
 
+func Start(name string, node *rundata.Node) error {
+	klog.V(2).Infof("[%s] [start] Start %s", node.HostInfo.Host, name)
+	if err := node.Run(tmpl.Start(name)); err != nil {
+		return fmt.Errorf("[%s] [start] Failed to start %s: %v", node.HostInfo.Host, name, err)
+	}
+	return nil
+}
+
 func Restart(name string, node *rundata.Node) error {
 	klog.V(2).Infof("[%s] [restart] Restart %s", node.HostInfo.Host, name)
 	if err := node.Run(tmpl.Restart(name)); err != nil {
@@ -0,0 +1,188 @@
+package upgrade
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/klog"
+
+	"github.com/yuyicai/kubei/internal/phases/kubeadm"
+	"github.com/yuyicai/kubei/internal/phases/system"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/internal/tmpl"
+)
+
+// CheckVersionSkew checks that the control plane can be upgraded to the Kubernetes version of the cluster,
+// and that the kubelets are not too old for it. The versions are read in dry run mode too, so the skew is checked
+// against the real cluster.
+func CheckVersionSkew(c *rundata.Cluster) error {
+	target, err := version.ParseSemantic(c.Kubernetes.Version)
+	if err != nil {
+		return fmt.Errorf("[upgrade] Invalid Kubernetes version %q, a version like \"v1.18.2\" is needed: %v", c.Kubernetes.Version, err)
+	}
+
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [upgrade] Checking the version skew", node.HostInfo.Host)
+		serverVersion, err := node.ReadFact(tmpl.ServerVersion())
+		if err != nil {
+			return fmt.Errorf("[%s] [upgrade] Failed to get the version of the control plane: %v", node.HostInfo.Host, err)
+		}
+		kubeletVersions, err := node.ReadFact(tmpl.KubeletVersions())
+		if err != nil {
+			return fmt.Errorf("[%s] [upgrade] Failed to get the kubelet version of the nodes: %v", node.HostInfo.Host, err)
+		}
+
+		current, err := version.ParseGeneric(strings.TrimSpace(string(serverVersion)))
+		if err != nil {
+			return fmt.Errorf("[%s] [upgrade] Failed to parse the version of the control plane: %v", node.HostInfo.Host, err)
+		}
+		if err := checkControlPlaneSkew(current, target); err != nil {
+			return fmt.Errorf("[%s] [upgrade] Can not upgrade the cluster from v%s to v%s: %v", node.HostInfo.Host, current, target, err)
+		}
+		if err := checkKubeletSkew(string(kubeletVersions), target); err != nil {
+			return fmt.Errorf("[%s] [upgrade] Can not upgrade the cluster to v%s: %v", node.HostInfo.Host, target, err)
+		}
+
+		fmt.Printf("[%s] [upgrade] check the version skew from v%s to v%s: %s\n", node.HostInfo.Host, current, target, color.HiGreenString("done✅️"))
+		return nil
+	})
+}
+
+// checkControlPlaneSkew allows the same version, to resume a failed upgrade, and the upgrades of at most one minor version.
+func checkControlPlaneSkew(current, target *version.Version) error {
+	if target.Major() != current.Major() {
+		return fmt.Errorf("the major version can not be changed")
+	}
+	if target.LessThan(current) {
+		return fmt.Errorf("downgrade is not supported")
+	}
+	if target.Minor() > current.Minor()+1 {
+		return fmt.Errorf("the minor version can only be upgraded one at a time")
+	}
+	return nil
+}
+
+// checkKubeletSkew checks that the kubelets are at most two minor versions older than the upgraded control plane.
+func checkKubeletSkew(kubeletVersions string, target *version.Version) error {
+	for _, line := range strings.Split(strings.TrimSpace(kubeletVersions), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		kubelet, err := version.ParseGeneric(fields[1])
+		if err != nil {
+			return fmt.Errorf("failed to parse the kubelet version of node %s: %v", fields[0], err)
+		}
+		if kubelet.Minor()+2 < target.Minor() {
+			return fmt.Errorf("the kubelet of node %s is v%s, it can be at most two minor versions older than the control plane", fields[0], kubelet)
+		}
+	}
+	return nil
+}
+
+// UpgradeFirstMaster upgrades the control plane with kubeadm upgrade apply on the first master.
+func UpgradeFirstMaster(c *rundata.Cluster) error {
+	color.HiBlue("Upgrading the first master to v%s ☸️", c.Kubernetes.Version)
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		return upgradeNode(c, node, func(node *rundata.Node) error {
+			if err := node.Run(tmpl.UpgradePlan(c.Kubernetes.Version)); err != nil {
+				return err
+			}
+			return node.Run(tmpl.UpgradeApply(c.Kubernetes.Version))
+		})
+	})
+}
+
+// UpgradeOtherMasters upgrades the other masters one by one.
+func UpgradeOtherMasters(c *rundata.Cluster) error {
+	return c.RunOnOtherMastersOneByOne(func(node *rundata.Node) error {
+		color.HiBlue("Upgrading master %s to v%s ☸️", node.Name, c.Kubernetes.Version)
+		return upgradeNode(c, node, upgradeKubeadmNode)
+	})
+}
+
+// UpgradeWorkers upgrades the workers in batches, the workers of a batch are upgraded at the same time.
+// It stops at the first batch that fails.
+func UpgradeWorkers(c *rundata.Cluster) error {
+	workers := c.ClusterNodes.Workers
+	size := c.Upgrade.WorkerBatchSize
+	for i := 0; i < len(workers); i += size {
+		end := i + size
+		if end > len(workers) {
+			end = len(workers)
+		}
+
+		batch := c.WithNodes(c.ClusterNodes.Masters, workers[i:end])
+		if err := batch.RunOnWorkersAndPrintLog(func(node *rundata.Node) error {
+			return upgradeNode(c, node, upgradeKubeadmNode)
+		}, color.HiBlueString("Upgrading workers %d-%d of %d to v%s ☸️", i+1, end, len(workers), c.Kubernetes.Version)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upgradeNode drains the node through the first master and upgrades it the way of kubeadm: kubeadm is upgraded first
+// and upgrades the node, then the kubelet and kubectl are upgraded and the kubelet is restarted, and the node is uncordoned.
+// The kubelet is upgraded last, so it never runs against an older control plane or kubelet configuration.
+func upgradeNode(c *rundata.Cluster, node *rundata.Node, upgrade func(*rundata.Node) error) error {
+	if err := kubeadm.DrainNode(c, node.Name); err != nil {
+		return err
+	}
+
+	cmdTmpl := tmpl.NewKubeText(node.PackageManagementType)
+	klog.V(2).Infof("[%s] [upgrade] Upgrading kubeadm to v%s", node.HostInfo.Host, c.Kubernetes.Version)
+	if err := upgradeKubeComponent(cmdTmpl.UpgradeKubeadm, c.Kubernetes.Version, node, c.Proxy); err != nil {
+		return fmt.Errorf("[%s] [upgrade] Failed to upgrade kubeadm: %v", node.HostInfo.Host, err)
+	}
+
+	klog.V(2).Infof("[%s] [upgrade] Upgrading node with kubeadm", node.HostInfo.Host)
+	if err := upgrade(node); err != nil {
+		return fmt.Errorf("[%s] [upgrade] Failed to upgrade node with kubeadm: %v", node.HostInfo.Host, err)
+	}
+
+	klog.V(2).Infof("[%s] [upgrade] Upgrading kubelet and kubectl to v%s", node.HostInfo.Host, c.Kubernetes.Version)
+	if err := upgradeKubeComponent(cmdTmpl.UpgradeKubelet, c.Kubernetes.Version, node, c.Proxy); err != nil {
+		return fmt.Errorf("[%s] [upgrade] Failed to upgrade kubelet and kubectl: %v", node.HostInfo.Host, err)
+	}
+
+	if err := system.Restart("kubelet", node); err != nil {
+		return err
+	}
+
+	if err := uncordonNode(c, node.Name); err != nil {
+		return err
+	}
+
+	fmt.Printf("[%s] [upgrade] upgrade node to v%s: %s\n", node.HostInfo.Host, c.Kubernetes.Version, color.HiGreenString("done✅️"))
+	return nil
+}
+
+func upgradeKubeComponent(text func(version, installType string) (string, error), version string, node *rundata.Node, p rundata.Proxy) error {
+	cmd, err := text(version, node.InstallType)
+	if err != nil {
+		return err
+	}
+	env, err := tmpl.ProxyEnv(node.InstallType, p)
+	if err != nil {
+		return err
+	}
+	return node.Run(env + cmd)
+}
+
+func upgradeKubeadmNode(node *rundata.Node) error {
+	return node.Run(tmpl.UpgradeNode())
+}
+
+func uncordonNode(c *rundata.Cluster, nodeName string) error {
+	return c.RunOnFirstMaster(func(node *rundata.Node) error {
+		klog.V(2).Infof("[%s] [uncordon] Uncordoning node %s", node.HostInfo.Host, nodeName)
+		if err := node.Run(tmpl.UncordonNode(nodeName)); err != nil {
+			return fmt.Errorf("[%s] [uncordon] Failed to uncordon node %s: %v", node.HostInfo.Host, nodeName, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,96 @@
+package upgrade
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestCheckControlPlaneSkew(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		target  string
+		wantErr string
+	}{
+		{name: "one minor version", current: "v1.17.5", target: "1.18.2"},
+		{name: "patch version", current: "v1.18.2", target: "1.18.6"},
+		{name: "same version to resume a failed upgrade", current: "v1.18.2", target: "1.18.2"},
+		{name: "major version", current: "v1.18.2", target: "2.0.0", wantErr: "the major version can not be changed"},
+		{name: "downgrade of the minor version", current: "v1.18.2", target: "1.17.5", wantErr: "downgrade is not supported"},
+		{name: "downgrade of the patch version", current: "v1.18.6", target: "1.18.2", wantErr: "downgrade is not supported"},
+		{name: "two minor versions", current: "v1.16.9", target: "1.18.2", wantErr: "the minor version can only be upgraded one at a time"},
+		{name: "three minor versions", current: "v1.15.12", target: "1.18.2", wantErr: "the minor version can only be upgraded one at a time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkControlPlaneSkew(version.MustParseGeneric(tt.current), version.MustParseSemantic(tt.target))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkControlPlaneSkew() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkControlPlaneSkew() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckKubeletSkew(t *testing.T) {
+	tests := []struct {
+		name            string
+		kubeletVersions string
+		target          string
+		wantErr         string
+	}{
+		{
+			name:            "same version",
+			kubeletVersions: "master-1 v1.17.5\nworker-1 v1.17.5\n",
+			target:          "1.18.2",
+		},
+		{
+			name:            "two minor versions behind",
+			kubeletVersions: "master-1 v1.18.2\nworker-1 v1.16.9\n",
+			target:          "1.18.2",
+		},
+		{
+			name:            "more than two minor versions behind",
+			kubeletVersions: "master-1 v1.17.5\nworker-1 v1.15.12\n",
+			target:          "1.18.2",
+			wantErr:         "the kubelet of node worker-1 is v1.15.12",
+		},
+		{
+			name:            "lines without a version are skipped",
+			kubeletVersions: "\nmaster-1\nworker-1 v1.17.5\n",
+			target:          "1.18.2",
+		},
+		{
+			name:            "no node",
+			kubeletVersions: "",
+			target:          "1.18.2",
+		},
+		{
+			name:            "invalid version",
+			kubeletVersions: "worker-1 unknown",
+			target:          "1.18.2",
+			wantErr:         "failed to parse the kubelet version of node worker-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkKubeletSkew(tt.kubeletVersions, version.MustParseSemantic(tt.target))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkKubeletSkew() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkKubeletSkew() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+	gossh "golang.org/x/crypto/ssh"
 	"k8s.io/klog"
 
 	"github.com/yuyicai/kubei/internal/constants"
@@ -14,71 +15,114 @@ import (
 
 func Prepare(c *rundata.Cluster) error {
 	color.HiBlue("Checking SSH connect 🌐")
-	return c.RunOnAllNodes(func(node *rundata.Node) error {
-		return check(node, c.Kubei)
-	})
+	hostKeyCallback, err := ssh.NewHostKeyCallback(c.SSH.HostKeyPolicy, c.SSH.KnownHostsFile)
+	if err != nil {
+		return fmt.Errorf("[preflight] Failed to set host key verification: %v", err)
+	}
+
+	if c.Dialer == nil {
+		c.Dialer = ssh.NewDialer()
+	}
+
+	if err := c.RunOnAllNodes(func(node *rundata.Node) error {
+		return check(node, c, hostKeyCallback)
+	}); err != nil {
+		return err
+	}
+
+	// the facts of the nodes are checked above, the scripts of the phases are only recorded from here
+	if c.DryRun.Enabled {
+		color.HiYellow("Dry run mode, the scripts are not run on the nodes 📝")
+		for _, node := range c.ClusterNodes.GetAllNodes() {
+			node.DryRun = &rundata.DryRun{}
+		}
+	}
+	return nil
 }
 
 func CloseSSH(c *rundata.Cluster) error {
-	return c.RunOnAllNodes(func(node *rundata.Node) error {
+	err := c.RunOnAllNodes(func(node *rundata.Node) error {
 		klog.V(1).Infof("[%s][close] Close ssh connect", node.HostInfo.Host)
 		return node.SSH.Close()
 	})
-}
 
-func check(node *rundata.Node, cfg *rundata.Kubei) error {
-	if err := jumpServerCheck(&cfg.JumpServer); err != nil {
-		return fmt.Errorf("[preflight] Failed to set jump server: %v", err)
-	}
-	return nodesCheck(node, cfg)
-}
-
-func jumpServerCheck(jumpServer *rundata.JumpServer) error {
-	if jumpServer.HostInfo.Host != "" && jumpServer.Client == nil {
-		hostInfo := jumpServer.HostInfo
-		klog.V(5).Infof("[preflight] Checking jump server %s", hostInfo.Host)
-		var err error
-		jumpServer.Client, err = ssh.Connect(hostInfo.Host, hostInfo.Port, hostInfo.User, hostInfo.Password, hostInfo.Key)
-		if err != nil {
-			return err
+	if c.Dialer != nil {
+		if closeErr := c.Dialer.Close(); err == nil {
+			err = closeErr
 		}
-		fmt.Printf("[%s] [preflight] jump server SSH connect: %s\n", hostInfo.Host, color.HiGreenString("done✅️"))
-		return nil
 	}
+	return err
+}
 
-	return nil
+func check(node *rundata.Node, c *rundata.Cluster, hostKeyCallback gossh.HostKeyCallback) error {
+	return nodesCheck(node, c, hostKeyCallback)
 }
 
-func nodesCheck(node *rundata.Node, cfg *rundata.Kubei) error {
+func nodesCheck(node *rundata.Node, c *rundata.Cluster, hostKeyCallback gossh.HostKeyCallback) error {
 
-	if err := sshCheck(node, &cfg.JumpServer); err != nil {
+	if err := sshCheck(node, c, hostKeyCallback); err != nil {
 		return fmt.Errorf("[%s] [preflight] Failed to set ssh connect: %v", node.HostInfo.Host, err)
 	}
 
 	return packageManagementTypeCheck(node)
 }
 
-func sshCheck(node *rundata.Node, jumpServer *rundata.JumpServer) error {
+func sshCheck(node *rundata.Node, c *rundata.Cluster, hostKeyCallback gossh.HostKeyCallback) error {
 	if node.SSH == nil {
-		return setSSHConnect(node, jumpServer)
+		return setSSHConnect(node, c, hostKeyCallback)
 	}
 	return nil
 }
 
-func setSSHConnect(node *rundata.Node, jumpServer *rundata.JumpServer) error {
+func setSSHConnect(node *rundata.Node, c *rundata.Cluster, hostKeyCallback gossh.HostKeyCallback) error {
 	var err error
 	userInfo := node.HostInfo
-	//Set up ssh connection through jump server
-	if jumpServer.HostInfo.Host != "" {
-		fmt.Printf("[%s] [preflight] SSH connect (through jump server %s\n): %s", userInfo.Host, jumpServer.HostInfo.Host, color.HiGreenString("done✅️"))
-		node.SSH, err = ssh.ConnectByJumpServer(userInfo.Host, userInfo.Port, userInfo.User, userInfo.Password, userInfo.Key, jumpServer.Client)
-		return err
-	} else {
-		//Set up ssh connection direct
-		fmt.Printf("[%s] [preflight] SSH connect: %s\n", userInfo.Host, color.HiGreenString("done✅️"))
-		node.SSH, err = ssh.Connect(userInfo.Host, userInfo.Port, userInfo.User, userInfo.Password, userInfo.Key)
+
+	// the jump servers of the command line take precedence over the ProxyJump of the ssh_config file
+	jumpServers := c.JumpServers
+	if len(jumpServers) == 0 {
+		jumpServers = node.JumpServers
+	}
+
+	var hops []ssh.Hop
+	var jumpHosts []string
+	for _, jumpServer := range jumpServers {
+		hops = append(hops, sshHop(jumpServer, hostKeyCallback))
+		jumpHosts = append(jumpHosts, jumpServer.Host)
+	}
+	hops = append(hops, sshHop(userInfo, hostKeyCallback))
+
+	node.SSH, err = c.Dialer.Connect(hops)
+	if err != nil {
 		return err
 	}
+
+	if len(jumpHosts) > 0 {
+		//Set up ssh connection through jump servers
+		fmt.Printf("[%s] [preflight] SSH connect (through jump server %s): %s\n", userInfo.Host, strings.Join(jumpHosts, " -> "), color.HiGreenString("done✅️"))
+		return nil
+	}
+	fmt.Printf("[%s] [preflight] SSH connect: %s\n", userInfo.Host, color.HiGreenString("done✅️"))
+	return nil
+}
+
+func sshHop(hostInfo rundata.HostInfo, hostKeyCallback gossh.HostKeyCallback) ssh.Hop {
+	return ssh.Hop{
+		Host:   hostInfo.Host,
+		Port:   hostInfo.Port,
+		Config: sshConfig(hostInfo, hostKeyCallback),
+	}
+}
+
+func sshConfig(hostInfo rundata.HostInfo, hostKeyCallback gossh.HostKeyCallback) *ssh.Config {
+	return &ssh.Config{
+		User:            hostInfo.User,
+		Password:        hostInfo.Password,
+		Key:             hostInfo.Key,
+		Passphrase:      hostInfo.Passphrase,
+		AuthMethods:     hostInfo.AuthMethods,
+		HostKeyCallback: hostKeyCallback,
+	}
 }
 
 func packageManagementTypeCheck(node *rundata.Node) error {
@@ -80,7 +80,7 @@ func (c *Cert) CreateAsCA(node *Node, ic *kubeadmapi.InitConfiguration) error {
 
 func (c *Cert) CreateKubeConfig(ic *kubeadmapi.InitConfiguration, caCert *x509.Certificate) error {
 
-	if !c.IsKubeConfig {
+	if !c.IsKubeConfig || c.Cert == nil {
 		return nil
 	}
 
@@ -108,7 +108,8 @@ func (c *Cert) CreateKubeConfig(ic *kubeadmapi.InitConfiguration, caCert *x509.C
 // CertificateTree is represents a one-level-deep tree, mapping a CA to the certs that depend on it.
 type CertificateTree map[*Cert]Certificates
 
-// Create creates the CAs, certs signed by the CAs.
+// Create creates the CAs, certs signed by the CAs. The existing CAs are never regenerated,
+// and the certs of an external CA, whose key is not known, are not created.
 func (t CertificateTree) Create(node *Node, ic *kubeadmapi.InitConfiguration, notAfterTime time.Duration) error {
 	for ca, leaves := range t {
 		if ca.Cert == nil {
@@ -124,6 +125,10 @@ func (t CertificateTree) Create(node *Node, ic *kubeadmapi.InitConfiguration, no
 			}
 		}
 
+		if ca.Key == nil {
+			continue
+		}
+
 		for _, leaf := range leaves {
 			leaf.Config.NotAfterTime = notAfterTime
 			if err := leaf.CreateFromCA(node, ic, ca.Cert, ca.Key); err != nil {
@@ -503,7 +508,8 @@ func GetAPIServerAltNames(node *Node, cfg *kubeadmapi.InitConfiguration) (*certu
 		}
 	}
 
-	exAltNames := append(cfg.APIServer.CertSANs, node.Name)
+	// copy the SANs, the certificates of the masters are created at the same time
+	exAltNames := append(append([]string{}, cfg.APIServer.CertSANs...), node.Name)
 	appendSANsToAltNames(altNames, exAltNames, kubeadmconstants.APIServerCertName)
 
 	return altNames, nil
@@ -566,7 +572,7 @@ func getAltNames(node *Node, cfg *kubeadmapi.InitConfiguration, certName string)
 		if certName == kubeadmconstants.EtcdServerCertName {
 			appendSANsToAltNames(altNames, cfg.Etcd.Local.ServerCertSANs, kubeadmconstants.EtcdServerCertName)
 		} else if certName == kubeadmconstants.EtcdPeerCertName {
-			exAltNames := append(cfg.Etcd.Local.PeerCertSANs, node.Name)
+			exAltNames := append(append([]string{}, cfg.Etcd.Local.PeerCertSANs...), node.Name)
 			appendSANsToAltNames(altNames, exAltNames, kubeadmconstants.EtcdPeerCertName)
 		}
 	}
@@ -1,8 +1,38 @@
 package rundata
 
+import (
+	"strings"
+
+	"github.com/yuyicai/kubei/internal/constants"
+)
+
 type ContainerEngine struct {
-	Type   string
-	Docker Docker
+	Type       string
+	Docker     Docker
+	Containerd Containerd
+	CRIO       CRIO
+	Registry   Registry
+}
+
+type Registry struct {
+	// Mirrors are the mirrors of docker.io set by the user, nil if they are not set and empty to disable the mirrors
+	Mirrors            []string
+	InsecureRegistries []string
+	Auths              []RegistryAuth
+}
+
+// DockerIOMirrors returns the mirrors of docker.io, the default mirrors are used if the user did not set them
+func (r Registry) DockerIOMirrors() []string {
+	if r.Mirrors == nil {
+		return strings.Split(constants.DefaultRegistryMirrors, ",")
+	}
+	return r.Mirrors
+}
+
+type RegistryAuth struct {
+	Registry string
+	Username string
+	Password string
 }
 
 type Docker struct {
@@ -12,3 +42,36 @@ type Docker struct {
 	LogOptsMaxSize string
 	StorageDriver  string
 }
+
+type Containerd struct {
+	Version      string
+	CGroupDriver string
+}
+
+type CRIO struct {
+	// Version is the minor version of CRI-O, e.g. 1.18
+	Version      string
+	CGroupDriver string
+}
+
+// CGroupDriver returns the cgroup driver of the container engine, the kubelet must use the same driver
+func (c *ContainerEngine) CGroupDriver() string {
+	switch c.Type {
+	case constants.ContainerEngineTypeContainerd:
+		return c.Containerd.CGroupDriver
+	case constants.ContainerEngineTypeCRIO:
+		return c.CRIO.CGroupDriver
+	}
+	return c.Docker.CGroupDriver
+}
+
+// CRISocket returns the CRI socket of the container engine, it is empty for Docker as kubeadm defaults to dockershim
+func (c *ContainerEngine) CRISocket() string {
+	switch c.Type {
+	case constants.ContainerEngineTypeContainerd:
+		return constants.ContainerdCRISocket
+	case constants.ContainerEngineTypeCRIO:
+		return constants.CRIOCRISocket
+	}
+	return ""
+}
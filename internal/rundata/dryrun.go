@@ -0,0 +1,27 @@
+package rundata
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/lithammer/dedent"
+)
+
+// DryRun records the scripts that would run on a node, in order.
+type DryRun struct {
+	mu      sync.Mutex
+	scripts []string
+}
+
+func (d *DryRun) record(script string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scripts = append(d.scripts, strings.TrimSpace(dedent.Dedent(script)))
+}
+
+// Scripts returns the recorded scripts in the order they would run.
+func (d *DryRun) Scripts() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.scripts...)
+}
@@ -3,6 +3,9 @@ package rundata
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+
 	"github.com/go-kratos/kratos/pkg/sync/errgroup"
 	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/pkg/ssh"
@@ -11,6 +14,8 @@ import (
 type ClusterNodes struct {
 	Masters []*Node
 	Workers []*Node
+	// PublicHostInfo holds the SSH host info of the nodes whose own host info is not set
+	PublicHostInfo HostInfo
 }
 
 func (c *ClusterNodes) GetAllMastersHost() []string {
@@ -89,25 +94,67 @@ func (c *ClusterNodes) LogRun(f func(node *Node) error) error {
 type Node struct {
 	SSH                   *ssh.Client
 	HostInfo              HostInfo
+	JumpServers           []HostInfo
 	CertificateTree       CertificateTree
 	Name                  string
 	PackageManagementType string
 	InstallType           string
 	IsSend                bool
+	// DryRun records the scripts instead of running them when it is set
+	DryRun *DryRun
 }
 
 type HostInfo struct {
-	Host     string
-	User     string
-	Password string
-	Port     string
-	Key      string
+	Host       string
+	User       string
+	Password   string
+	Port       string
+	Key        string
+	Passphrase string
+	// AuthMethods are the SSH authentication methods tried in order, see ssh.DefaultAuthMethods
+	AuthMethods []string `mapstructure:"auth-methods"`
 }
 
 func (n *Node) Run(cmd string) error {
+	if n.IsDryRun() {
+		n.DryRun.record(cmd)
+		return nil
+	}
 	return n.SSH.Run(cmd)
 }
 
+// RunOut runs the command and returns its output, the output is empty in dry run mode.
 func (n *Node) RunOut(cmd string) ([]byte, error) {
+	if n.IsDryRun() {
+		n.DryRun.record(cmd)
+		return []byte{}, nil
+	}
 	return n.SSH.RunOut(cmd)
 }
+
+// ReadFact runs a read-only command and returns its output, it runs in dry run mode too and is not recorded,
+// so the facts of the node are the real ones. It must not change the node.
+func (n *Node) ReadFact(cmd string) ([]byte, error) {
+	return n.SSH.RunOut(cmd)
+}
+
+func (n *Node) SendFile(dstFile, srcFile string) error {
+	if n.IsDryRun() {
+		n.DryRun.record(fmt.Sprintf("# send local file %s to %s", srcFile, dstFile))
+		return nil
+	}
+	return n.SSH.SendFile(dstFile, srcFile)
+}
+
+// SendData writes the data to the remote file with the mode through SFTP, the data is never recorded or logged.
+func (n *Node) SendData(dstFile string, data []byte, mode os.FileMode) error {
+	if n.IsDryRun() {
+		n.DryRun.record(fmt.Sprintf("# send %d bytes to %s with mode %04o", len(data), dstFile, mode))
+		return nil
+	}
+	return n.SSH.SendData(dstFile, data, mode)
+}
+
+func (n *Node) IsDryRun() bool {
+	return n.DryRun != nil
+}
@@ -16,7 +16,9 @@ import (
 type Cluster struct {
 	*Kubei
 	Kubeadm *Kubeadm
-	Mutex   sync.Mutex
+	// Dialer holds the SSH connections to the jump servers
+	Dialer *ssh.Dialer
+	Mutex  sync.Mutex
 }
 
 func (c *Cluster) RunOnAllNodes(f func(*Node) error) error {
@@ -76,6 +78,22 @@ func (c *Cluster) RunOnFirstMaster(f func(*Node) error) error {
 	return runOne(c.ClusterNodes.Masters[0], f)
 }
 
+// WithNodes returns a copy of the cluster with only the given nodes, the nodes are shared with the cluster.
+// It is used to run the phases on a part of an existing cluster, e.g. on the nodes joining the cluster.
+func (c *Cluster) WithNodes(masters, workers []*Node) *Cluster {
+	k := *c.Kubei
+	k.ClusterNodes = ClusterNodes{
+		Masters:        masters,
+		Workers:        workers,
+		PublicHostInfo: c.ClusterNodes.PublicHostInfo,
+	}
+	return &Cluster{
+		Kubei:   &k,
+		Kubeadm: c.Kubeadm,
+		Dialer:  c.Dialer,
+	}
+}
+
 func run(nodes []*Node, f func(*Node) error) error {
 	g := errgroup.WithCancel(context.Background())
 	g.GOMAXPROCS(constants.DefaultGOMAXPROCS)
@@ -97,19 +115,60 @@ type Kubei struct {
 	ContainerEngine  ContainerEngine
 	Kubernetes       Kubernetes
 	ClusterNodes     ClusterNodes
+	SSH              SSH
 	NetworkPlugins   NetworkPlugins
 	HA               HA
-	JumpServer       JumpServer
+	JumpServers      []HostInfo
 	Install          Install
 	Reset            Reset
+	Upgrade          Upgrade
 	Addons           Addons
+	Proxy            Proxy
 	OfflineFile      string
 	CertNotAfterTime int
+	// CADir is the local directory of the CAs of the cluster, the CAs are created by kubei when it is empty
+	CADir string
+	// KeyAlgorithm is the algorithm of the keys created by kubei: rsa or ecdsa
+	KeyAlgorithm string
+	DryRun       DryRunOptions
+
+	// ServiceAccountKey is the PEM encoded key pair for signing service account tokens, it is created by the cert phase
+	ServiceAccountKey KeyPair
+}
+
+type Proxy struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	// NoProxy are the hosts that are not proxied set by the user
+	NoProxy []string
+	// AllNoProxy is NoProxy with the nodes, the pod and service subnets and the control plane endpoint, it is set by the defaults
+	AllNoProxy []string
 }
 
-type JumpServer struct {
-	*ssh.Client
-	HostInfo HostInfo
+// IsSet returns true if a proxy is set
+func (p *Proxy) IsSet() bool {
+	return p.HTTPProxy != "" || p.HTTPSProxy != ""
+}
+
+type KeyPair struct {
+	Key       []byte
+	PublicKey []byte
+}
+
+type DryRunOptions struct {
+	Enabled bool
+	// Dir is the directory to write the scripts of each node to, the scripts are printed when it is empty
+	Dir string
+}
+
+type SSH struct {
+	// HostKeyPolicy is one of strict, accept-new and insecure
+	HostKeyPolicy  string
+	KnownHostsFile string
+	// ConfigFile is the ssh_config file used to resolve the nodes and the jump servers
+	ConfigFile string
+	// ProxyJump is the chain of jump servers "[user@]host[:port],...", it is resolved to Kubei.JumpServers
+	ProxyJump string
 }
 
 type Reset struct {
@@ -117,12 +176,23 @@ type Reset struct {
 	RemoveKubeComponent   bool
 }
 
+type Upgrade struct {
+	// WorkerBatchSize is the number of workers that are drained and upgraded at the same time
+	WorkerBatchSize int
+}
+
 type Install struct {
 	Type string
 }
 
 type Kubeadm struct {
 	kubeadmapi.InitConfiguration
+	// KubeProxy is the KubeProxyConfiguration of the cluster passed to kubeadm, it is not passed when it is empty
+	KubeProxy map[string]interface{}
+	// ProxyMode is the mode of kube-proxy: iptables or ipvs, the IPVS kernel modules and tools are set up on the nodes for ipvs
+	ProxyMode string
+	// Kubelet is the KubeletConfiguration of the cluster passed to kubeadm, it is not passed when it is empty
+	Kubelet map[string]interface{}
 }
 
 func NewKubei() *Kubei {
@@ -130,7 +200,6 @@ func NewKubei() *Kubei {
 		ContainerEngine: ContainerEngine{},
 		Kubernetes:      Kubernetes{},
 		ClusterNodes:    ClusterNodes{},
-		JumpServer:      JumpServer{},
 		Install:         Install{},
 		Reset:           Reset{},
 		Addons:          Addons{},
@@ -149,7 +218,6 @@ func NewCluster() *Cluster {
 			ContainerEngine: ContainerEngine{},
 			Kubernetes:      Kubernetes{},
 			ClusterNodes:    ClusterNodes{},
-			JumpServer:      JumpServer{},
 			Install:         Install{},
 			Reset:           Reset{},
 			Addons:          Addons{},
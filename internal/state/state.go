@@ -0,0 +1,171 @@
+// Package state saves and locates the local state of the clusters created by kubei.
+//
+// The state of a cluster is kept in ~/.kubei/clusters/<name>: state.yaml is a kubei configuration file
+// that describes the nodes as they were resolved, plus the status of the cluster (the bootstrap token,
+// the CA certificate hash, the install type of each node), and pki holds the CAs and the service account key pair.
+package state
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"k8s.io/klog"
+	kubeadmpkiutil "k8s.io/kubernetes/cmd/kubeadm/app/util/pkiutil"
+
+	"github.com/yuyicai/kubei/internal/config"
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/pkg/pki"
+)
+
+// Dir returns the state directory of the cluster.
+func Dir(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid cluster name %q", name)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the home directory: %v", err)
+	}
+	return filepath.Join(home, constants.StateDir, name), nil
+}
+
+// File returns the state file of the cluster, an error is returned if the cluster has no state.
+func File(name string) (string, error) {
+	dir, err := Dir(name)
+	if err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(dir, constants.StateFileName)
+	if _, err := os.Stat(file); err != nil {
+		return "", fmt.Errorf("unable to find the state of cluster %q: %v", name, err)
+	}
+	return file, nil
+}
+
+// PKIDir returns the directory of the CAs of the cluster.
+func PKIDir(name string) (string, error) {
+	dir, err := Dir(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, constants.StatePKIDir), nil
+}
+
+// Save writes the state file and the CAs of the cluster, nothing is written in dry run mode.
+// The directories are only accessible by the current user, as the files hold the bootstrap token and the CA keys.
+func Save(c *rundata.Cluster) error {
+	if c.DryRun.Enabled {
+		return nil
+	}
+
+	dir, err := Dir(c.Kubeadm.ClusterName)
+	if err != nil {
+		return err
+	}
+	klog.V(2).Infof("[state] Saving the state of cluster %q to %s", c.Kubeadm.ClusterName, dir)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("[state] Failed to create the state directory %s: %v", dir, err)
+	}
+
+	b, err := config.Encode(config.ConvertFrom(c))
+	if err != nil {
+		return fmt.Errorf("[state] Failed to encode the state of cluster %q: %v", c.Kubeadm.ClusterName, err)
+	}
+	if err := writeFile(filepath.Join(dir, constants.StateFileName), b); err != nil {
+		return err
+	}
+
+	if err := savePKI(filepath.Join(dir, constants.StatePKIDir), c); err != nil {
+		return err
+	}
+
+	fmt.Printf("[state] The state of cluster %q is saved to %s, use \"--cluster %s\" to manage the cluster: %s\n",
+		c.Kubeadm.ClusterName, dir, c.Kubeadm.ClusterName, color.HiGreenString("done✅️"))
+	return nil
+}
+
+// savePKI writes the CAs of the first master with the layout of /etc/kubernetes/pki,
+// it is skipped if the certificates were not created by kubei.
+func savePKI(dir string, c *rundata.Cluster) error {
+	if len(c.ClusterNodes.Masters) == 0 {
+		return nil
+	}
+
+	for ca := range c.ClusterNodes.Masters[0].CertificateTree {
+		if ca.Cert == nil || ca.Key == nil {
+			continue
+		}
+
+		key, err := pki.EncodePrivateKeyPEM(ca.Key)
+		if err != nil {
+			return fmt.Errorf("[state] Failed to encode the key of %s: %v", ca.Name, err)
+		}
+		if err := writeFile(filepath.Join(dir, ca.BaseName+".crt"), pki.EncodeCertPEM(ca.Cert)); err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dir, ca.BaseName+".key"), key); err != nil {
+			return err
+		}
+	}
+
+	if sa := c.ServiceAccountKey; len(sa.Key) > 0 {
+		if err := writeFile(filepath.Join(dir, "sa.key"), sa.Key); err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dir, "sa.pub"), sa.PublicKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadPKI loads the CAs and the service account key pair saved by Save,
+// the CAs are empty if the certificates of the cluster were not created by kubei.
+func LoadPKI(name string) (rundata.Certificates, rundata.KeyPair, error) {
+	dir, err := PKIDir(name)
+	if err != nil {
+		return nil, rundata.KeyPair{}, err
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, rundata.CertRootCA.BaseName+".crt")); os.IsNotExist(err) {
+		klog.V(2).Infof("[state] There are no CAs of cluster %q in %s", name, dir)
+		return nil, rundata.KeyPair{}, nil
+	}
+
+	var cas rundata.Certificates
+	for _, ca := range []rundata.Cert{rundata.CertRootCA, rundata.CertFrontProxyCA, rundata.CertEtcdCA} {
+		ca := ca
+		ca.Cert, ca.Key, err = kubeadmpkiutil.TryLoadCertAndKeyFromDisk(dir, ca.BaseName)
+		if err != nil {
+			return nil, rundata.KeyPair{}, fmt.Errorf("[state] Failed to load the CA %s of cluster %q: %v", ca.Name, name, err)
+		}
+		cas = append(cas, &ca)
+	}
+
+	var sa rundata.KeyPair
+	if sa.Key, err = ioutil.ReadFile(filepath.Join(dir, "sa.key")); err != nil {
+		return nil, rundata.KeyPair{}, fmt.Errorf("[state] Failed to load the service account key of cluster %q: %v", name, err)
+	}
+	if sa.PublicKey, err = ioutil.ReadFile(filepath.Join(dir, "sa.pub")); err != nil {
+		return nil, rundata.KeyPair{}, fmt.Errorf("[state] Failed to load the service account public key of cluster %q: %v", name, err)
+	}
+	return cas, sa, nil
+}
+
+func writeFile(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("[state] Failed to create the directory of %s: %v", file, err)
+	}
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("[state] Failed to write %s: %v", file, err)
+	}
+	return nil
+}
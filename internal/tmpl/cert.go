@@ -0,0 +1,40 @@
+package tmpl
+
+import (
+	"fmt"
+
+	"github.com/lithammer/dedent"
+)
+
+// CatFile prints the file, nothing is printed if the file does not exist
+func CatFile(file string) string {
+	return fmt.Sprintf("if [ -f %[1]s ]; then cat %[1]s; fi", file)
+}
+
+// RestartControlPlane restarts the control plane static Pods by moving their manifests out of the manifests directory
+// until the kubelet stops them
+func RestartControlPlane() string {
+	return dedent.Dedent(`
+        mkdir -p /etc/kubernetes/manifests-kubei
+        for c in kube-apiserver kube-controller-manager kube-scheduler etcd; do
+          if [ -f /etc/kubernetes/manifests/$c.yaml ]; then
+            mv /etc/kubernetes/manifests/$c.yaml /etc/kubernetes/manifests-kubei/
+          fi
+        done
+        sleep 20
+        for f in /etc/kubernetes/manifests-kubei/*.yaml; do
+          if [ -f $f ]; then
+            mv $f /etc/kubernetes/manifests/
+          fi
+        done
+        rmdir /etc/kubernetes/manifests-kubei
+	`)
+}
+
+// WaitAPIServerHealthy waits for the apiserver of the master to be healthy
+func WaitAPIServerHealthy(bindPort int32, timeoutSeconds int) string {
+	cmdTmpl := dedent.Dedent(`
+        timeout %d sh -c 'until kubectl --kubeconfig /etc/kubernetes/admin.conf --server https://127.0.0.1:%d get --raw /healthz >/dev/null 2>&1; do sleep 5; done'
+	`)
+	return fmt.Sprintf(cmdTmpl, timeoutSeconds, bindPort)
+}
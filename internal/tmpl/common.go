@@ -11,6 +11,11 @@ func Restart(name string) string {
 	return fmt.Sprintf(cmdTmpl, name, name)
 }
 
+// Start enables and starts the service, it is not restarted if it is running
+func Start(name string) string {
+	return fmt.Sprintf("systemctl enable %[1]s && systemctl start %[1]s", name)
+}
+
 func SetHosts(ip, apiDomainName string) string {
 	cmdTmpl := dedent.Dedent(`
         sed -i '/%s/d' /etc/hosts
@@ -44,3 +49,20 @@ func Iptables() string {
 	`)
 	return cmd
 }
+
+// IPVSModules loads the kernel modules of the ipvs mode of kube-proxy and loads them on boot,
+// nf_conntrack_ipv4 is merged into nf_conntrack since Linux 4.19
+func IPVSModules() string {
+	return dedent.Dedent(`
+        CONNTRACK=nf_conntrack
+        modinfo nf_conntrack_ipv4 >/dev/null 2>&1 && CONNTRACK=nf_conntrack_ipv4
+        cat <<EOF | tee /etc/modules-load.d/ipvs.conf
+        ip_vs
+        ip_vs_rr
+        ip_vs_wrr
+        ip_vs_sh
+        $CONNTRACK
+        EOF
+        modprobe -a ip_vs ip_vs_rr ip_vs_wrr ip_vs_sh $CONNTRACK
+	`)
+}
@@ -2,52 +2,469 @@ package tmpl
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"github.com/lithammer/dedent"
 	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/internal/rundata"
+	"github.com/yuyicai/kubei/pkg/redact"
+	"reflect"
+	"strings"
 	"text/template"
 )
 
 type DocekrText interface {
 	Docker(installTyped string, dockerData rundata.Docker) (string, error)
+	DockerDaemonConfig(current []byte, dockerData rundata.Docker, registry rundata.Registry) ([]byte, bool, error)
+	Containerd(installType string, containerdData rundata.Containerd) (string, error)
+	CRIO(installType string, crioData rundata.CRIO, pauseImage string, registry rundata.Registry) (string, error)
 	RemoveDocker() string
+	RemoveContainerd() string
+	RemoveCRIO() string
+}
+
+// mergeDockerDaemonConfig merges the settings managed by kubei into the current daemon.json of a node: the registries,
+// the cgroup driver, the log driver and the storage driver. The other settings such as data-root, bip,
+// default-address-pools or runtimes are kept. It reports whether the merged settings differ from the current ones.
+func mergeDockerDaemonConfig(current []byte, d rundata.Docker, r rundata.Registry, storageOpts []string) ([]byte, bool, error) {
+	currentCfg, err := parseDockerDaemonConfig(current)
+	if err != nil {
+		return nil, false, err
+	}
+	// the current settings are parsed twice, so the merged settings can be compared with them
+	cfg, _ := parseDockerDaemonConfig(current)
+
+	// the registries are only changed when the user set them, so the ones set by the base image of the node are kept.
+	// The default mirrors are only added if the node has no mirrors, an empty list set by the user removes them
+	if r.Mirrors != nil {
+		setDockerDaemonList(cfg, "registry-mirrors", r.Mirrors)
+	} else if _, ok := cfg["registry-mirrors"]; !ok {
+		setDockerDaemonList(cfg, "registry-mirrors", r.DockerIOMirrors())
+	}
+	if len(r.InsecureRegistries) > 0 {
+		setDockerDaemonList(cfg, "insecure-registries", r.InsecureRegistries)
+	}
+
+	// the cgroup driver replaces the current one in place, the other exec-opts are kept
+	cgroupDriver := "native.cgroupdriver=" + d.CGroupDriver
+	var execOpts []string
+	for _, opt := range dockerDaemonList(cfg["exec-opts"]) {
+		if !strings.HasPrefix(opt, "native.cgroupdriver=") {
+			execOpts = append(execOpts, opt)
+		} else if !contains(execOpts, cgroupDriver) {
+			execOpts = append(execOpts, cgroupDriver)
+		}
+	}
+	if !contains(execOpts, cgroupDriver) {
+		execOpts = append(execOpts, cgroupDriver)
+	}
+	setDockerDaemonList(cfg, "exec-opts", execOpts)
+
+	cfg["log-driver"] = d.LogDriver
+	logOpts, ok := cfg["log-opts"].(map[string]interface{})
+	if !ok {
+		logOpts = map[string]interface{}{}
+	}
+	logOpts["max-size"] = d.LogOptsMaxSize
+	cfg["log-opts"] = logOpts
+
+	cfg["storage-driver"] = d.StorageDriver
+	if len(storageOpts) > 0 {
+		opts := dockerDaemonList(cfg["storage-opts"])
+		for _, opt := range storageOpts {
+			if !contains(opts, opt) {
+				opts = append(opts, opt)
+			}
+		}
+		setDockerDaemonList(cfg, "storage-opts", opts)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, false, err
+	}
+	return append(data, '\n'), !reflect.DeepEqual(currentCfg, cfg), nil
+}
+
+func parseDockerDaemonConfig(data []byte) (map[string]interface{}, error) {
+	cfg := map[string]interface{}{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return cfg, nil
+	}
+
+	// the numbers are kept as they are written
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon.json: %v", err)
+	}
+	if cfg == nil {
+		cfg = map[string]interface{}{}
+	}
+	return cfg, nil
+}
+
+// dockerDaemonList returns the strings of a list of daemon.json
+func dockerDaemonList(v interface{}) []string {
+	var list []string
+	items, _ := v.([]interface{})
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// setDockerDaemonList sets a list of daemon.json with the types of a parsed list, the list is removed if it is empty
+func setDockerDaemonList(cfg map[string]interface{}, key string, list []string) {
+	if len(list) == 0 {
+		delete(cfg, key)
+		return
+	}
+	items := make([]interface{}, 0, len(list))
+	for _, s := range list {
+		items = append(items, s)
+	}
+	cfg[key] = items
+}
+
+type dockerAuth struct {
+	Auth string `json:"auth"`
+}
+
+// DockerConfig merges the credentials of the registries into the current docker config file of a node, the format read
+// by Docker, the kubelet and CRI-O. The other credentials, e.g. of docker login, and the other settings such as
+// credHelpers or credsStore are kept.
+func DockerConfig(current []byte, auths []rundata.RegistryAuth) ([]byte, error) {
+	cfg := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(current)) > 0 {
+		if err := json.Unmarshal(current, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse the docker config file: %v", err)
+		}
+	}
+	if cfg == nil {
+		cfg = map[string]json.RawMessage{}
+	}
+
+	currentAuths := map[string]json.RawMessage{}
+	if raw, ok := cfg["auths"]; ok {
+		if err := json.Unmarshal(raw, &currentAuths); err != nil {
+			return nil, fmt.Errorf("failed to parse the auths of the docker config file: %v", err)
+		}
+		if currentAuths == nil {
+			currentAuths = map[string]json.RawMessage{}
+		}
+	}
+
+	for _, a := range auths {
+		registry := a.Registry
+		// Docker keeps the credentials of docker.io under the address of its index
+		if registry == "docker.io" {
+			registry = "https://index.docker.io/v1/"
+		}
+		auth := base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password))
+		redact.Add(auth)
+		entry, err := json.Marshal(dockerAuth{Auth: auth})
+		if err != nil {
+			return nil, err
+		}
+		currentAuths[registry] = entry
+	}
+
+	raw, err := json.Marshal(currentAuths)
+	if err != nil {
+		return nil, err
+	}
+	cfg["auths"] = raw
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the docker config file: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// containerdConfig loads the kernel modules needed by containerd and points crictl to it,
+// the configuration of containerd is rendered by ContainerdConfig
+var containerdConfig = dedent.Dedent(`
+	{{ define "config" }}
+	cat <<EOF | tee /etc/modules-load.d/containerd.conf
+	overlay
+	br_netfilter
+	EOF
+	modprobe -a overlay br_netfilter
+	cat <<EOF | tee /etc/crictl.yaml
+	runtime-endpoint: unix://{{ .criSocket }}
+	image-endpoint: unix://{{ .criSocket }}
+	EOF
+	{{ end }}
+`)
+
+// ContainerdConfig renders /etc/containerd/config.toml with the version 2 format of containerd 1.3+.
+// It holds the credentials of the registries, so it is sent to the nodes instead of being written by a command.
+// The insecure registries are tried over https without verifying their certificates, then over http like Docker does.
+func ContainerdConfig(c rundata.Containerd, sandboxImage string, r rundata.Registry) (string, error) {
+	var auths []rundata.RegistryAuth
+	for _, a := range r.Auths {
+		a.Registry = containerdRegistryHost(a.Registry)
+		auths = append(auths, a)
+	}
+	m := map[string]interface{}{
+		"cgroupDriver": c.CGroupDriver,
+		"sandboxImage": sandboxImage,
+		"registries":   containerdRegistries(r),
+		"auths":        auths,
+	}
+	t, err := template.New("text").Funcs(template.FuncMap{"toml": tomlString}).Parse(dedent.Dedent(`
+		version = 2
+		[plugins."io.containerd.grpc.v1.cri"]
+		  sandbox_image = "{{ .sandboxImage }}"
+		  [plugins."io.containerd.grpc.v1.cri".containerd]
+		    default_runtime_name = "runc"
+		    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+		      runtime_type = "io.containerd.runc.v2"
+		      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+		        SystemdCgroup = {{ eq .cgroupDriver "systemd" }}
+		{{- range .registries }}
+		  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."{{ .Name }}"]
+		    endpoint = [{{ range $i, $e := .Endpoints }}{{ if $i }}, {{ end }}"{{ $e }}"{{ end }}]
+		{{- if .Insecure }}
+		  [plugins."io.containerd.grpc.v1.cri".registry.configs."{{ .Host }}".tls]
+		    insecure_skip_verify = true
+		{{- end }}
+		{{- end }}
+		{{- range .auths }}
+		  [plugins."io.containerd.grpc.v1.cri".registry.configs."{{ .Registry }}".auth]
+		    username = {{ toml .Username }}
+		    password = {{ toml .Password }}
+		{{- end }}
+	`))
+	if err != nil {
+		return "", err
+	}
+
+	var buff bytes.Buffer
+	if err := t.Execute(&buff, m); err != nil {
+		return "", err
+	}
+
+	return buff.String(), nil
+}
+
+// containerdRegistry is a registry of the containerd configuration
+type containerdRegistry struct {
+	// Name is the registry of the image references
+	Name string
+	// Host is the host containerd connects to, the TLS and auth configurations are keyed by it
+	Host      string
+	Endpoints []string
+	Insecure  bool
+}
+
+// containerdRegistries returns the registries of the containerd configuration, each registry once as containerd
+// fails to start with duplicate tables. The mirrors of docker.io and docker.io itself as an insecure registry
+// are merged into the endpoints of docker.io.
+func containerdRegistries(r rundata.Registry) []containerdRegistry {
+	var registries []containerdRegistry
+	index := map[string]int{}
+
+	if mirrors := r.DockerIOMirrors(); len(mirrors) > 0 {
+		index["docker.io"] = len(registries)
+		registries = append(registries, containerdRegistry{
+			Name:      "docker.io",
+			Host:      containerdRegistryHost("docker.io"),
+			Endpoints: append(append([]string{}, mirrors...), "https://"+containerdRegistryHost("docker.io")),
+		})
+	}
+
+	for _, registry := range r.InsecureRegistries {
+		host := containerdRegistryHost(registry)
+		i, ok := index[registry]
+		if !ok {
+			i = len(registries)
+			index[registry] = i
+			registries = append(registries, containerdRegistry{Name: registry, Host: host})
+		}
+		if registries[i].Insecure {
+			continue
+		}
+		registries[i].Insecure = true
+		registries[i].Endpoints = append(registries[i].Endpoints, "https://"+host, "http://"+host)
+		registries[i].Endpoints = uniqueStrings(registries[i].Endpoints)
+	}
+	return registries
+}
+
+// containerdRegistryHost returns the host containerd connects to for the registry
+func containerdRegistryHost(registry string) string {
+	if registry == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return registry
+}
+
+func uniqueStrings(s []string) []string {
+	var u []string
+	seen := map[string]bool{}
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			u = append(u, v)
+		}
+	}
+	return u
+}
+
+// tomlString quotes the string as a TOML basic string, the escapes of Go like \x00 are not valid in TOML
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
 }
 
 type KubeText interface {
 	KubeComponent(version, installType string) (string, error)
+	UpgradeKubeadm(version, installType string) (string, error)
+	UpgradeKubelet(version, installType string) (string, error)
+	IPVS(installType string) (string, error)
 	RemoveKubeComponent() string
 }
 
+// aptKubeRepo adds the Kubernetes apt repository
+var aptKubeRepo = dedent.Dedent(`
+	{{ define "repo" }}
+	apt-get update -qq && apt-get install -qq -y apt-transport-https curl
+	curl -s https://mirrors.aliyun.com/kubernetes/apt/doc/apt-key.gpg | apt-key add - >/dev/null
+	cat <<EOF | tee /etc/apt/sources.list.d/kubernetes.list
+	deb https://mirrors.aliyun.com/kubernetes/apt/ kubernetes-xenial main
+	EOF
+	apt-get update -qq
+	{{- end }}
+`)
+
+// yumKubeRepo adds the Kubernetes yum repository
+var yumKubeRepo = dedent.Dedent(`
+	{{ define "repo" }}
+	cat <<EOF | tee /etc/yum.repos.d/kubernetes.repo
+	[kubernetes]
+	name=Kubernetes
+	baseurl=https://mirrors.aliyun.com/kubernetes/yum/repos/kubernetes-el7-x86_64
+	enabled=1
+	gpgcheck=1
+	repo_gpgcheck=1
+	gpgkey=https://mirrors.aliyun.com/kubernetes/yum/doc/yum-key.gpg https://mirrors.aliyun.com/kubernetes/yum/doc/rpm-package-key.gpg
+	EOF
+	{{- end }}
+`)
+
 type Apt struct {
 }
 
+// crioConfig loads the kernel modules needed by CRI-O, writes the drop-in configuration of kubei
+// and the registries of containers/image, and points crictl to CRI-O.
+// The conmon cgroup must be "pod" with the cgroupfs cgroup manager
+var crioConfig = dedent.Dedent(`
+	{{ define "config" }}
+	cat <<EOF | tee /etc/modules-load.d/crio.conf
+	overlay
+	br_netfilter
+	EOF
+	modprobe -a overlay br_netfilter
+	mkdir -p /etc/crio/crio.conf.d
+	cat <<EOF | tee /etc/crio/crio.conf.d/01-kubei.conf
+	[crio.runtime]
+	cgroup_manager = "{{ .cgroupDriver }}"
+	{{- if eq .cgroupDriver "systemd" }}
+	conmon_cgroup = "system.slice"
+	{{- else }}
+	conmon_cgroup = "pod"
+	{{- end }}
+	
+	[crio.image]
+	pause_image = "{{ .pauseImage }}"
+	{{- if .auths }}
+	global_auth_file = "{{ .authFile }}"
+	{{- end }}
+	registries = [
+	  "docker.io",
+	]
+	EOF
+	{{- if or .mirrors .insecureRegistries }}
+	mkdir -p /etc/containers
+	cat <<EOF | tee /etc/containers/registries.conf
+	unqualified-search-registries = ["docker.io"]
+	{{- if .mirrors }}
+	
+	[[registry]]
+	prefix = "docker.io"
+	location = "docker.io"
+	{{- range .mirrors }}
+	
+	[[registry.mirror]]
+	location = "{{ .Location }}"
+	{{- if .Insecure }}
+	insecure = true
+	{{- end }}
+	{{- end }}
+	{{- end }}
+	{{- range .insecureRegistries }}
+	
+	[[registry]]
+	location = "{{ . }}"
+	insecure = true
+	{{- end }}
+	EOF
+	{{- end }}
+	cat <<EOF | tee /etc/crictl.yaml
+	runtime-endpoint: unix://{{ .criSocket }}
+	image-endpoint: unix://{{ .criSocket }}
+	EOF
+	{{ end }}
+`)
+
 func (Apt) Docker(installTyped string, d rundata.Docker) (string, error) {
 	m := map[string]interface{}{
-		"version":        d.Version,
-		"cgroupDriver":   d.CGroupDriver,
-		"logDriver":      d.LogDriver,
-		"logOptsMaxSize": d.LogOptsMaxSize,
-		"storageDriver":  d.StorageDriver,
+		"version": d.Version,
 	}
 	t, err := template.New("text").Parse(dedent.Dedent(`
 		{{ define "config" }}
 		mkdir -p /etc/docker/ || true
-		cat <<EOF | tee /etc/docker/daemon.json
-		{
-		  "registry-mirrors": [
-		      "https://dockerhub.mirrors.nwafu.edu.cn/",
-		      "https://hub-mirror.c.163.com"
-		  ],
-		{{- if eq .cgroupDriver "systemd" }}
-		  "exec-opts": ["native.cgroupdriver=systemd"],
-		{{- end }}
-		  "log-driver": "{{ .logDriver }}",
-		  "log-opts": {
-		    "max-size": "{{ .logOptsMaxSize }}"
-		  },
-		  "storage-driver": "{{ .storageDriver }}"
-		}
-		EOF
 		mkdir -p /etc/systemd/system/docker.service.d || true
 		{{ end }}
 		{{ define "online" }}
@@ -82,49 +499,101 @@ func (Apt) Docker(installTyped string, d rundata.Docker) (string, error) {
 	return cmdBuff.String(), nil
 }
 
-func (Apt) Containerd(version string) (string, error) {
+func (Apt) DockerDaemonConfig(current []byte, d rundata.Docker, r rundata.Registry) ([]byte, bool, error) {
+	return mergeDockerDaemonConfig(current, d, r, nil)
+}
+
+func (Apt) Containerd(installType string, c rundata.Containerd) (string, error) {
 	m := map[string]interface{}{
-		"version": version,
+		"version":   c.Version,
+		"criSocket": constants.ContainerdCRISocket,
 	}
-	t, err := template.New("ver").Parse(dedent.Dedent(`
-        apt-get update -qq >/dev/null && DEBIAN_FRONTEND=noninteractive apt-get -y install apt-transport-https ca-certificates curl
-        curl -fsSL https://mirrors.aliyun.com/docker-ce/linux/ubuntu/gpg | apt-key add -qq - >/dev/null
-        cat <<EOF | tee /etc/apt/sources.list.d/docker.list
-        deb [arch=amd64] https://mirrors.aliyun.com/docker-ce/linux/ubuntu $(lsb_release -cs) stable
-        EOF
-        apt-get update -qq >/dev/null
-        {{- if ne .version "" }}
-        CONTAINERD_VER=$(apt-cache madison containerd.io | awk '/{{ .version }}/ {print$3}' | head -1)
-        apt-get -y install -qq containerd.io=$CONTAINERD_VER
-        {{- else }}
-        apt-get -y install -qq containerd.io
-        {{- end }}
+	t, err := template.New("text").Parse(containerdConfig + dedent.Dedent(`
+		{{ define "online" }}
+		apt-get update -qq >/dev/null && DEBIAN_FRONTEND=noninteractive apt-get -y install -qq apt-transport-https ca-certificates curl
+		curl -fsSL https://mirrors.aliyun.com/docker-ce/linux/ubuntu/gpg | apt-key add -qq - >/dev/null
+		cat <<EOF | tee /etc/apt/sources.list.d/docker.list
+		deb [arch=amd64] https://mirrors.aliyun.com/docker-ce/linux/ubuntu $(lsb_release -cs) stable
+		EOF
+		apt-get update -qq >/dev/null
+		{{- if ne .version "" }}
+		CONTAINERD_VER=$(apt-cache madison containerd.io | awk '/{{ .version }}/ {print$3}' | head -1)
+		apt-get -y install -qq containerd.io=$CONTAINERD_VER
+		{{- else }}
+		apt-get -y install -qq containerd.io
+		{{- end }}
+		{{- template "config" . -}}
+		{{ end }}
+		{{ define "offline" }}
+		sh /tmp/.kubei/container_engine/default.sh
+		{{- template "config" . -}}
+		{{ end }}
 	`))
 	if err != nil {
 		return "", err
 	}
 
 	var cmdBuff bytes.Buffer
-	if err := t.Execute(&cmdBuff, m); err != nil {
+	if err := t.ExecuteTemplate(&cmdBuff, installType, m); err != nil {
 		return "", err
 	}
 
-	cmd := cmdBuff.String()
-	return cmd, nil
+	return cmdBuff.String(), nil
+}
+
+func (Apt) CRIO(installType string, c rundata.CRIO, pauseImage string, r rundata.Registry) (string, error) {
+	m := map[string]interface{}{
+		"version":            c.Version,
+		"cgroupDriver":       c.CGroupDriver,
+		"pauseImage":         pauseImage,
+		"criSocket":          constants.CRIOCRISocket,
+		"mirrors":            crioMirrors(r.DockerIOMirrors()),
+		"insecureRegistries": r.InsecureRegistries,
+		"auths":              r.Auths,
+		"authFile":           constants.KubeletCredentialFile,
+	}
+	t, err := template.New("text").Parse(crioConfig + dedent.Dedent(`
+		{{ define "online" }}
+		apt-get update -qq >/dev/null && DEBIAN_FRONTEND=noninteractive apt-get -y install -qq apt-transport-https ca-certificates curl gnupg
+		. /etc/os-release
+		OS=Debian_$VERSION_ID
+		[ "$ID" = "ubuntu" ] && OS=xUbuntu_$VERSION_ID
+		cat <<EOF | tee /etc/apt/sources.list.d/devel:kubic:libcontainers:stable.list
+		deb https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable/$OS/ /
+		EOF
+		cat <<EOF | tee /etc/apt/sources.list.d/devel:kubic:libcontainers:stable:cri-o:{{ .version }}.list
+		deb https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable:/cri-o:/{{ .version }}/$OS/ /
+		EOF
+		curl -fsSL https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable/$OS/Release.key | apt-key add -qq - >/dev/null
+		curl -fsSL https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable:/cri-o:/{{ .version }}/$OS/Release.key | apt-key add -qq - >/dev/null
+		apt-get update -qq >/dev/null
+		DEBIAN_FRONTEND=noninteractive apt-get -y install -qq cri-o cri-o-runc
+		{{- template "config" . -}}
+		{{ end }}
+		{{ define "offline" }}
+		sh /tmp/.kubei/container_engine/default.sh
+		{{- template "config" . -}}
+		{{ end }}
+	`))
+	if err != nil {
+		return "", err
+	}
+
+	var cmdBuff bytes.Buffer
+	if err := t.ExecuteTemplate(&cmdBuff, installType, m); err != nil {
+		return "", err
+	}
+
+	return cmdBuff.String(), nil
 }
 
 func (Apt) KubeComponent(version, installType string) (string, error) {
 	m := map[string]interface{}{
 		"version": version,
 	}
-	t, err := template.New("text").Parse(dedent.Dedent(`
+	t, err := template.New("text").Parse(aptKubeRepo + dedent.Dedent(`
 		{{ define "online" }}
-		apt-get update -qq && apt-get install -qq -y apt-transport-https curl
-		curl -s https://mirrors.aliyun.com/kubernetes/apt/doc/apt-key.gpg | apt-key add - >/dev/null
-		cat <<EOF | tee /etc/apt/sources.list.d/kubernetes.list
-		deb https://mirrors.aliyun.com/kubernetes/apt/ kubernetes-xenial main
-		EOF
-		apt-get update -qq
+		{{- template "repo" . }}
 		{{- if ne .version "" }}
 		KUBE_VER=$(apt-cache madison kubelet | awk '/{{ .version }}/ {print$3}' | head -1)
 		apt-get install -qq -y --allow-change-held-packages kubelet=$KUBE_VER kubeadm=$KUBE_VER kubectl=$KUBE_VER
@@ -150,10 +619,69 @@ func (Apt) KubeComponent(version, installType string) (string, error) {
 	return cmd, nil
 }
 
+// UpgradeKubeadm upgrades kubeadm only, the kubelet and kubectl are upgraded by UpgradeKubelet after kubeadm upgraded the node.
+// The kubeadm package of the offline package is installed without its dependencies, they are installed with the kubelet
+func (Apt) UpgradeKubeadm(version, installType string) (string, error) {
+	return executeKubeUpgrade(aptKubeRepo+dedent.Dedent(`
+		{{ define "online" }}
+		{{- template "repo" . }}
+		KUBE_VER=$(apt-cache madison kubeadm | awk '/{{ .version }}/ {print$3}' | head -1)
+		apt-get install -qq -y --allow-change-held-packages kubeadm=$KUBE_VER
+		apt-mark hold kubeadm
+		{{ end }}
+		{{ define "offline" }}
+		dpkg -i --force-depends $(find /tmp/.kubei/kube -name 'kubeadm*.deb' | head -1)
+		{{ end }}
+	`), version, installType)
+}
+
+// UpgradeKubelet upgrades the kubelet and kubectl, the repository is added by UpgradeKubeadm
+func (Apt) UpgradeKubelet(version, installType string) (string, error) {
+	return executeKubeUpgrade(dedent.Dedent(`
+		{{ define "online" }}
+		KUBE_VER=$(apt-cache madison kubelet | awk '/{{ .version }}/ {print$3}' | head -1)
+		apt-get install -qq -y --allow-change-held-packages kubelet=$KUBE_VER kubectl=$KUBE_VER
+		apt-mark hold kubelet kubectl
+		{{ end }}
+		{{ define "offline" }}
+		sh /tmp/.kubei/kube/default.sh
+		{{ end }}
+	`), version, installType)
+}
+
+func (Apt) IPVS(installType string) (string, error) {
+	t, err := template.New("text").Parse(dedent.Dedent(`
+		{{ define "online" }}
+		apt-get update -qq && DEBIAN_FRONTEND=noninteractive apt-get install -qq -y ipset ipvsadm
+		{{ end }}
+		{{ define "offline" }}
+		sh /tmp/.kubei/ipvs/default.sh
+		{{ end }}
+	`))
+	if err != nil {
+		return "", err
+	}
+
+	var cmdBuff bytes.Buffer
+	if err := t.ExecuteTemplate(&cmdBuff, installType, nil); err != nil {
+		return "", err
+	}
+
+	return cmdBuff.String(), nil
+}
+
 func (Apt) RemoveDocker() string {
 	return "apt-get remove -y docker-ce docker-ce-cli containerd.io || true"
 }
 
+func (Apt) RemoveContainerd() string {
+	return "apt-get remove -y containerd.io || true"
+}
+
+func (Apt) RemoveCRIO() string {
+	return "apt-get remove -y cri-o cri-o-runc || true"
+}
+
 func (Apt) RemoveKubeComponent() string {
 	return "apt-get remove -y --allow-change-held-packages kubelet kubeadm kubectl || true"
 }
@@ -163,37 +691,12 @@ type Yum struct {
 
 func (Yum) Docker(installType string, d rundata.Docker) (string, error) {
 	m := map[string]interface{}{
-		"version":        d.Version,
-		"cgroupDriver":   d.CGroupDriver,
-		"logDriver":      d.LogDriver,
-		"logOptsMaxSize": d.LogOptsMaxSize,
-		"storageDriver":  d.StorageDriver,
+		"version": d.Version,
 	}
 	t, err := template.New("text").Parse(dedent.Dedent(`
 		{{ define "config" }}
 		mkdir -p /etc/docker/ || true
-		cat <<EOF | tee /etc/docker/daemon.json
-		{
-		  "registry-mirrors": [
-		      "https://dockerhub.mirrors.nwafu.edu.cn/",
-		      "https://hub-mirror.c.163.com"
-		  ],
-		{{- if eq .cgroupDriver "systemd" }}
-		  "exec-opts": ["native.cgroupdriver=systemd"],
-		{{- end }}
-		  "log-driver": "{{ .logDriver }}",
-		  "log-opts": {
-		    "max-size": "{{ .logOptsMaxSize }}"
-		  },
-		{{- if eq .storageDriver "overlay2" }}
-		  "storage-driver": "overlay2",
-		  "storage-opts": [
-		    "overlay2.override_kernel_check=true"
-		  ]
-		{{- end }}
-		}
-		EOF
-		mkdir -p /etc/systemd/system/docker.service.d
+		mkdir -p /etc/systemd/system/docker.service.d || true
 		{{ end }}
 		{{ define "online" }}
 		yum install -y -q yum-utils
@@ -224,53 +727,100 @@ func (Yum) Docker(installType string, d rundata.Docker) (string, error) {
 	return cmdBuff.String(), nil
 }
 
-func (Yum) Containerd(version string) (string, error) {
+// DockerDaemonConfig skips the kernel version check of overlay2, as the kernel of CentOS 7 backports overlay2
+func (Yum) DockerDaemonConfig(current []byte, d rundata.Docker, r rundata.Registry) ([]byte, bool, error) {
+	var storageOpts []string
+	if d.StorageDriver == "overlay2" {
+		storageOpts = []string{"overlay2.override_kernel_check=true"}
+	}
+	return mergeDockerDaemonConfig(current, d, r, storageOpts)
+}
+
+func (Yum) Containerd(installType string, c rundata.Containerd) (string, error) {
 	m := map[string]interface{}{
-		"version": version,
+		"version":   c.Version,
+		"criSocket": constants.ContainerdCRISocket,
 	}
-	t, err := template.New("ver").Parse(dedent.Dedent(`
-        yum install -y -q yum-utils
-        yum-config-manager --add-repo \
-          https://mirrors.aliyun.com/docker-ce/linux/centos/docker-ce.repo
-        {{- if ne .version "" }}
-        CONTAINERD_VER=$(yum list docker-ce --showduplicates | awk '/{{ .version }}/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
-        yum install -y -q containerd.io=CONTAINERD_VER
-        {{- else }}
-        yum install -y -q containerd.io
-        {{- end }}
-    `))
+	t, err := template.New("text").Parse(containerdConfig + dedent.Dedent(`
+		{{ define "online" }}
+		yum install -y -q yum-utils
+		yum-config-manager --add-repo \
+		  https://mirrors.aliyun.com/docker-ce/linux/centos/docker-ce.repo
+		{{- if ne .version "" }}
+		CONTAINERD_VER=$(yum list containerd.io --showduplicates | awk '/{{ .version }}/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
+		yum install -y -q containerd.io-$CONTAINERD_VER
+		{{- else }}
+		yum install -y -q containerd.io
+		{{- end }}
+		{{- template "config" . -}}
+		{{ end }}
+		{{ define "offline" }}
+		sh /tmp/.kubei/container_engine/default.sh
+		{{- template "config" . -}}
+		{{ end }}
+	`))
 	if err != nil {
 		return "", err
 	}
 
 	var cmdBuff bytes.Buffer
-	if err := t.Execute(&cmdBuff, m); err != nil {
+	if err := t.ExecuteTemplate(&cmdBuff, installType, m); err != nil {
 		return "", err
 	}
 
-	cmd := cmdBuff.String()
-	return cmd, nil
+	return cmdBuff.String(), nil
+}
+
+func (Yum) CRIO(installType string, c rundata.CRIO, pauseImage string, r rundata.Registry) (string, error) {
+	m := map[string]interface{}{
+		"version":            c.Version,
+		"cgroupDriver":       c.CGroupDriver,
+		"pauseImage":         pauseImage,
+		"criSocket":          constants.CRIOCRISocket,
+		"mirrors":            crioMirrors(r.DockerIOMirrors()),
+		"insecureRegistries": r.InsecureRegistries,
+		"auths":              r.Auths,
+		"authFile":           constants.KubeletCredentialFile,
+	}
+	t, err := template.New("text").Parse(crioConfig + dedent.Dedent(`
+		{{ define "online" }}
+		. /etc/os-release
+		OS=CentOS_${VERSION_ID%%.*}
+		curl -fsSL -o /etc/yum.repos.d/devel:kubic:libcontainers:stable.repo \
+		  https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable/$OS/devel:kubic:libcontainers:stable.repo
+		curl -fsSL -o /etc/yum.repos.d/devel:kubic:libcontainers:stable:cri-o:{{ .version }}.repo \
+		  https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable:/cri-o:/{{ .version }}/$OS/devel:kubic:libcontainers:stable:cri-o:{{ .version }}.repo
+		yum install -y -q cri-o
+		{{- template "config" . -}}
+		{{ end }}
+		{{ define "offline" }}
+		sh /tmp/.kubei/container_engine/default.sh
+		{{- template "config" . -}}
+		{{ end }}
+	`))
+	if err != nil {
+		return "", err
+	}
+
+	var cmdBuff bytes.Buffer
+	if err := t.ExecuteTemplate(&cmdBuff, installType, m); err != nil {
+		return "", err
+	}
+
+	return cmdBuff.String(), nil
 }
 
 func (Yum) KubeComponent(version, installType string) (string, error) {
 	m := map[string]interface{}{
 		"version": version,
 	}
-	t, err := template.New("ver").Parse(dedent.Dedent(`
+	t, err := template.New("ver").Parse(yumKubeRepo + dedent.Dedent(`
 		{{ define "selinux" }}
 		setenforce 0 || true
 		sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config
 		{{- end }}
 		{{ define "online" }}
-		cat <<EOF | tee /etc/yum.repos.d/kubernetes.repo
-		[kubernetes]
-		name=Kubernetes
-		baseurl=https://mirrors.aliyun.com/kubernetes/yum/repos/kubernetes-el7-x86_64
-		enabled=1
-		gpgcheck=1
-		repo_gpgcheck=1
-		gpgkey=https://mirrors.aliyun.com/kubernetes/yum/doc/yum-key.gpg https://mirrors.aliyun.com/kubernetes/yum/doc/rpm-package-key.gpg
-		EOF
+		{{- template "repo" . }}
 		{{- template "selinux" . -}}
 		{{- if ne .version "" }}
 		KUBE_VER=$(yum list kubelet --showduplicates | awk '/{{ .version }}/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
@@ -297,14 +847,103 @@ func (Yum) KubeComponent(version, installType string) (string, error) {
 	return cmd, nil
 }
 
+// UpgradeKubeadm upgrades kubeadm only, the kubelet and kubectl are upgraded by UpgradeKubelet after kubeadm upgraded the node.
+// The kubeadm package of the offline package is installed without its dependencies, they are installed with the kubelet
+func (Yum) UpgradeKubeadm(version, installType string) (string, error) {
+	return executeKubeUpgrade(yumKubeRepo+dedent.Dedent(`
+		{{ define "online" }}
+		{{- template "repo" . }}
+		KUBE_VER=$(yum list kubeadm --showduplicates | awk '/{{ .version }}/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
+		yum install -y kubeadm-$KUBE_VER --disableexcludes=kubernetes
+		{{ end }}
+		{{ define "offline" }}
+		rpm -Uvh --replacepkgs --nodeps $(find /tmp/.kubei/kube -name '*kubeadm*.rpm' | head -1)
+		{{ end }}
+	`), version, installType)
+}
+
+// UpgradeKubelet upgrades the kubelet and kubectl, the repository is added by UpgradeKubeadm
+func (Yum) UpgradeKubelet(version, installType string) (string, error) {
+	return executeKubeUpgrade(dedent.Dedent(`
+		{{ define "online" }}
+		KUBE_VER=$(yum list kubelet --showduplicates | awk '/{{ .version }}/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
+		yum install -y kubelet-$KUBE_VER kubectl-$KUBE_VER --disableexcludes=kubernetes
+		{{ end }}
+		{{ define "offline" }}
+		sh /tmp/.kubei/kube/default.sh
+		{{ end }}
+	`), version, installType)
+}
+
+func (Yum) IPVS(installType string) (string, error) {
+	t, err := template.New("text").Parse(dedent.Dedent(`
+		{{ define "online" }}
+		yum install -y -q ipset ipvsadm
+		{{ end }}
+		{{ define "offline" }}
+		sh /tmp/.kubei/ipvs/default.sh
+		{{ end }}
+	`))
+	if err != nil {
+		return "", err
+	}
+
+	var cmdBuff bytes.Buffer
+	if err := t.ExecuteTemplate(&cmdBuff, installType, nil); err != nil {
+		return "", err
+	}
+
+	return cmdBuff.String(), nil
+}
+
 func (Yum) RemoveDocker() string {
 	return "yum remove -y docker-ce docker-ce-cli containerd.io || true"
 }
 
+func (Yum) RemoveContainerd() string {
+	return "yum remove -y containerd.io || true"
+}
+
+func (Yum) RemoveCRIO() string {
+	return "yum remove -y cri-o || true"
+}
+
 func (Yum) RemoveKubeComponent() string {
 	return "yum remove -y kubelet kubeadm kubectl  || true"
 }
 
+func executeKubeUpgrade(text, version, installType string) (string, error) {
+	t, err := template.New("text").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var cmdBuff bytes.Buffer
+	if err := t.ExecuteTemplate(&cmdBuff, installType, map[string]interface{}{"version": version}); err != nil {
+		return "", err
+	}
+
+	return cmdBuff.String(), nil
+}
+
+type crioMirror struct {
+	Location string
+	Insecure bool
+}
+
+// crioMirrors returns the mirrors of docker.io for registries.conf, their locations have no scheme
+// so the mirrors over http are insecure
+func crioMirrors(mirrors []string) []crioMirror {
+	var m []crioMirror
+	for _, mirror := range mirrors {
+		m = append(m, crioMirror{
+			Location: strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(mirror, "https://"), "http://"), "/"),
+			Insecure: strings.HasPrefix(mirror, "http://"),
+		})
+	}
+	return m
+}
+
 func NewContainerEngineText(installationType string) DocekrText {
 	switch installationType {
 	case constants.PackageManagementTypeApt:
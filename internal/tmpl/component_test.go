@@ -4,9 +4,12 @@ import (
 	"github.com/lithammer/dedent"
 	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/internal/rundata"
+	"strings"
 	"testing"
 )
 
+var defaultRegistry = rundata.Registry{Mirrors: strings.Split(constants.DefaultRegistryMirrors, ",")}
+
 func TestApt_Docker(t *testing.T) {
 	type args struct {
 		i string
@@ -24,7 +27,7 @@ func TestApt_Docker(t *testing.T) {
 				i: constants.InstallTypeOnline,
 				d: rundata.Docker{
 					Version:        "18.09.9",
-					CGroupDriver:   constants.DefaultCGroupDriver,
+					CGroupDriver:   "systemd",
 					LogDriver:      constants.DefaultLogDriver,
 					LogOptsMaxSize: constants.DefaultLogOptsMaxSize,
 					StorageDriver:  constants.DockerDefaultStorageDriver,
@@ -39,21 +42,8 @@ func TestApt_Docker(t *testing.T) {
 				apt-get update -qq >/dev/null
 				DOCKER_VER=$(apt-cache madison docker-ce | awk '/18.09.9/ {print$3}' | head -1)
 				apt-get -y install -qq docker-ce=$DOCKER_VER docker-ce-cli=$DOCKER_VER containerd.io
-				cat <<EOF | tee /etc/docker/daemon.json
-				{
-				  "registry-mirrors": [
-				      "https://dockerhub.mirrors.nwafu.edu.cn/",
-				      "https://hub-mirror.c.163.com"
-				  ],
-				  "exec-opts": ["native.cgroupdriver=systemd"],
-				  "log-driver": "json-file",
-				  "log-opts": {
-				    "max-size": "500m"
-				  },
-				  "storage-driver": "overlay2"
-				}
-				EOF
-				mkdir -p /etc/systemd/system/docker.service.d
+				mkdir -p /etc/docker/ || true
+				mkdir -p /etc/systemd/system/docker.service.d || true
 				`),
 		},
 		{
@@ -62,7 +52,7 @@ func TestApt_Docker(t *testing.T) {
 				i: constants.InstallTypeOnline,
 				d: rundata.Docker{
 					Version:        "",
-					CGroupDriver:   constants.DefaultCGroupDriver,
+					CGroupDriver:   "systemd",
 					LogDriver:      constants.DefaultLogDriver,
 					LogOptsMaxSize: constants.DefaultLogOptsMaxSize,
 					StorageDriver:  constants.DockerDefaultStorageDriver,
@@ -76,21 +66,8 @@ func TestApt_Docker(t *testing.T) {
 				EOF
 				apt-get update -qq >/dev/null
 				apt-get -y install -qq docker-ce docker-ce-cli containerd.io
-				cat <<EOF | tee /etc/docker/daemon.json
-				{
-				  "registry-mirrors": [
-				      "https://dockerhub.mirrors.nwafu.edu.cn/",
-				      "https://hub-mirror.c.163.com"
-				  ],
-				  "exec-opts": ["native.cgroupdriver=systemd"],
-				  "log-driver": "json-file",
-				  "log-opts": {
-				    "max-size": "500m"
-				  },
-				  "storage-driver": "overlay2"
-				}
-				EOF
-				mkdir -p /etc/systemd/system/docker.service.d
+				mkdir -p /etc/docker/ || true
+				mkdir -p /etc/systemd/system/docker.service.d || true
 				`),
 		},
 		{
@@ -99,28 +76,15 @@ func TestApt_Docker(t *testing.T) {
 				i: constants.InstallTypeOffline,
 				d: rundata.Docker{
 					Version:        "18.09.9",
-					CGroupDriver:   constants.DefaultCGroupDriver,
+					CGroupDriver:   "systemd",
 					LogDriver:      constants.DefaultLogDriver,
 					LogOptsMaxSize: constants.DefaultLogOptsMaxSize,
 					StorageDriver:  constants.DockerDefaultStorageDriver,
 				},
 			},
 			want: dedent.Dedent(`
-				cat <<EOF | tee /etc/docker/daemon.json
-				{
-				  "registry-mirrors": [
-				      "https://dockerhub.mirrors.nwafu.edu.cn/",
-				      "https://hub-mirror.c.163.com"
-				  ],
-				  "exec-opts": ["native.cgroupdriver=systemd"],
-				  "log-driver": "json-file",
-				  "log-opts": {
-				    "max-size": "500m"
-				  },
-				  "storage-driver": "overlay2"
-				}
-				EOF
-				mkdir -p /etc/systemd/system/docker.service.d
+				mkdir -p /etc/docker/ || true
+				mkdir -p /etc/systemd/system/docker.service.d || true
 				sh /tmp/.kubei/container_engine/default.sh
 				`),
 		},
@@ -157,7 +121,7 @@ func TestYum_Docker(t *testing.T) {
 				i: "online",
 				d: rundata.Docker{
 					Version:        "18.09.9",
-					CGroupDriver:   constants.DefaultCGroupDriver,
+					CGroupDriver:   "systemd",
 					LogDriver:      constants.DefaultLogDriver,
 					LogOptsMaxSize: constants.DefaultLogOptsMaxSize,
 					StorageDriver:  constants.DockerDefaultStorageDriver,
@@ -169,25 +133,8 @@ func TestYum_Docker(t *testing.T) {
 				  https://mirrors.aliyun.com/docker-ce/linux/centos/docker-ce.repo
 				DOCKER_VER=$(yum list docker-ce --showduplicates | awk '/18.09.9/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
 				yum install -y -q docker-ce-$DOCKER_VER docker-ce-cli-$DOCKER_VER containerd.io
-				mkdir -p /etc/docker
-				cat <<EOF | tee /etc/docker/daemon.json
-				{
-				  "registry-mirrors": [
-				      "https://dockerhub.mirrors.nwafu.edu.cn/",
-				      "https://hub-mirror.c.163.com"
-				  ],
-				  "exec-opts": ["native.cgroupdriver=systemd"],
-				  "log-driver": "json-file",
-				  "log-opts": {
-				    "max-size": "500m"
-				  },
-				  "storage-driver": "overlay2",
-				  "storage-opts": [
-				    "overlay2.override_kernel_check=true"
-				  ]
-				}
-				EOF
-				mkdir -p /etc/systemd/system/docker.service.d
+				mkdir -p /etc/docker/ || true
+				mkdir -p /etc/systemd/system/docker.service.d || true
 				`),
 		},
 		{
@@ -196,7 +143,7 @@ func TestYum_Docker(t *testing.T) {
 				i: "online",
 				d: rundata.Docker{
 					Version:        "",
-					CGroupDriver:   constants.DefaultCGroupDriver,
+					CGroupDriver:   "systemd",
 					LogDriver:      constants.DefaultLogDriver,
 					LogOptsMaxSize: constants.DefaultLogOptsMaxSize,
 					StorageDriver:  constants.DockerDefaultStorageDriver,
@@ -207,25 +154,8 @@ func TestYum_Docker(t *testing.T) {
 				yum-config-manager --add-repo \
 				  https://mirrors.aliyun.com/docker-ce/linux/centos/docker-ce.repo
 				yum install -y -q docker-ce docker-ce-cli containerd.io
-				mkdir -p /etc/docker
-				cat <<EOF | tee /etc/docker/daemon.json
-				{
-				  "registry-mirrors": [
-				      "https://dockerhub.mirrors.nwafu.edu.cn/",
-				      "https://hub-mirror.c.163.com"
-				  ],
-				  "exec-opts": ["native.cgroupdriver=systemd"],
-				  "log-driver": "json-file",
-				  "log-opts": {
-				    "max-size": "500m"
-				  },
-				  "storage-driver": "overlay2",
-				  "storage-opts": [
-				    "overlay2.override_kernel_check=true"
-				  ]
-				}
-				EOF
-				mkdir -p /etc/systemd/system/docker.service.d
+				mkdir -p /etc/docker/ || true
+				mkdir -p /etc/systemd/system/docker.service.d || true
 				`),
 		},
 		{
@@ -234,46 +164,294 @@ func TestYum_Docker(t *testing.T) {
 				i: "offline",
 				d: rundata.Docker{
 					Version:        "18.09.9",
-					CGroupDriver:   constants.DefaultCGroupDriver,
+					CGroupDriver:   "systemd",
 					LogDriver:      constants.DefaultLogDriver,
 					LogOptsMaxSize: constants.DefaultLogOptsMaxSize,
 					StorageDriver:  constants.DockerDefaultStorageDriver,
 				},
 			},
 			want: dedent.Dedent(`
-				mkdir -p /etc/docker
-				cat <<EOF | tee /etc/docker/daemon.json
+				mkdir -p /etc/docker/ || true
+				mkdir -p /etc/systemd/system/docker.service.d || true
+				sh /tmp/.kubei/container_engine/default.sh
+				`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yu := Yum{}
+			got, err := yu.Docker(tt.args.i, tt.args.d)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Docker() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Docker() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDockerDaemonConfig(t *testing.T) {
+	d := rundata.Docker{
+		CGroupDriver:   "systemd",
+		LogDriver:      constants.DefaultLogDriver,
+		LogOptsMaxSize: constants.DefaultLogOptsMaxSize,
+		StorageDriver:  constants.DockerDefaultStorageDriver,
+	}
+	type args struct {
+		text    DocekrText
+		current string
+		r       rundata.Registry
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        string
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name: "(apt_docker) new daemon.json",
+			args: args{text: Apt{}},
+			want: dedent.Dedent(`
 				{
+				  "exec-opts": [
+				    "native.cgroupdriver=systemd"
+				  ],
+				  "log-driver": "json-file",
+				  "log-opts": {
+				    "max-size": "500m"
+				  },
 				  "registry-mirrors": [
-				      "https://dockerhub.mirrors.nwafu.edu.cn/",
-				      "https://hub-mirror.c.163.com"
+				    "https://dockerhub.mirrors.nwafu.edu.cn/",
+				    "https://hub-mirror.c.163.com"
+				  ],
+				  "storage-driver": "overlay2"
+				}
+			`)[1:],
+			wantChanged: true,
+		},
+		{
+			name: "(yum_docker) merged daemon.json keeps the settings not managed by kubei",
+			args: args{
+				text: Yum{},
+				current: `{
+				  "data-root": "/data/docker",
+				  "bip": "172.18.0.1/16",
+				  "mtu": 1450,
+				  "exec-opts": ["native.cgroupdriver=cgroupfs", "native.umask=normal"],
+				  "log-opts": {"max-file": "3", "max-size": "100m"},
+				  "registry-mirrors": ["https://mirror.example.com"],
+				  "runtimes": {"nvidia": {"path": "nvidia-container-runtime", "runtimeArgs": []}}
+				}`,
+				r: rundata.Registry{InsecureRegistries: []string{"harbor.k8s.local"}},
+			},
+			want: dedent.Dedent(`
+				{
+				  "bip": "172.18.0.1/16",
+				  "data-root": "/data/docker",
+				  "exec-opts": [
+				    "native.cgroupdriver=systemd",
+				    "native.umask=normal"
+				  ],
+				  "insecure-registries": [
+				    "harbor.k8s.local"
 				  ],
-				  "exec-opts": ["native.cgroupdriver=systemd"],
 				  "log-driver": "json-file",
 				  "log-opts": {
+				    "max-file": "3",
 				    "max-size": "500m"
 				  },
+				  "mtu": 1450,
+				  "registry-mirrors": [
+				    "https://mirror.example.com"
+				  ],
+				  "runtimes": {
+				    "nvidia": {
+				      "path": "nvidia-container-runtime",
+				      "runtimeArgs": []
+				    }
+				  },
 				  "storage-driver": "overlay2",
 				  "storage-opts": [
 				    "overlay2.override_kernel_check=true"
 				  ]
 				}
-				EOF
-				mkdir -p /etc/systemd/system/docker.service.d
-				sh /tmp/.kubei/container_engine/default.sh
-				`),
+			`)[1:],
+			wantChanged: true,
+		},
+		{
+			name: "(apt_docker) unchanged daemon.json",
+			args: args{
+				text: Apt{},
+				current: `{"exec-opts": ["native.cgroupdriver=systemd"], "log-driver": "json-file",
+				  "log-opts": {"max-size": "500m"}, "storage-driver": "overlay2", "data-root": "/data/docker"}`,
+				r: rundata.Registry{Mirrors: []string{}},
+			},
+			want: dedent.Dedent(`
+				{
+				  "data-root": "/data/docker",
+				  "exec-opts": [
+				    "native.cgroupdriver=systemd"
+				  ],
+				  "log-driver": "json-file",
+				  "log-opts": {
+				    "max-size": "500m"
+				  },
+				  "storage-driver": "overlay2"
+				}
+			`)[1:],
+			wantChanged: false,
+		},
+		{
+			name: "(apt_docker) registries of the node are kept if they are not set",
+			args: args{
+				text: Apt{},
+				current: `{"exec-opts": ["native.cgroupdriver=systemd"], "log-driver": "json-file",
+				  "log-opts": {"max-size": "500m"}, "storage-driver": "overlay2",
+				  "registry-mirrors": ["https://mirror.example.com"], "insecure-registries": ["10.3.0.5:5000"]}`,
+			},
+			want: dedent.Dedent(`
+				{
+				  "exec-opts": [
+				    "native.cgroupdriver=systemd"
+				  ],
+				  "insecure-registries": [
+				    "10.3.0.5:5000"
+				  ],
+				  "log-driver": "json-file",
+				  "log-opts": {
+				    "max-size": "500m"
+				  },
+				  "registry-mirrors": [
+				    "https://mirror.example.com"
+				  ],
+				  "storage-driver": "overlay2"
+				}
+			`)[1:],
+			wantChanged: false,
+		},
+		{
+			name: "(apt_docker) mirrors of the node are removed by an empty list",
+			args: args{
+				text: Apt{},
+				current: `{"exec-opts": ["native.cgroupdriver=systemd"], "log-driver": "json-file",
+				  "log-opts": {"max-size": "500m"}, "storage-driver": "overlay2",
+				  "registry-mirrors": ["https://mirror.example.com"]}`,
+				r: rundata.Registry{Mirrors: []string{}},
+			},
+			want: dedent.Dedent(`
+				{
+				  "exec-opts": [
+				    "native.cgroupdriver=systemd"
+				  ],
+				  "log-driver": "json-file",
+				  "log-opts": {
+				    "max-size": "500m"
+				  },
+				  "storage-driver": "overlay2"
+				}
+			`)[1:],
+			wantChanged: true,
+		},
+		{
+			name:    "(apt_docker) invalid daemon.json",
+			args:    args{text: Apt{}, current: `{"data-root": }`},
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			yu := Yum{}
-			got, err := yu.Docker(tt.args.i, tt.args.d)
+			got, changed, err := tt.args.text.DockerDaemonConfig([]byte(tt.args.current), d, tt.args.r)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("Docker() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("DockerDaemonConfig() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("Docker() got = %v, want %v", got, tt.want)
+			if string(got) != tt.want {
+				t.Errorf("DockerDaemonConfig() got = %v, want %v", string(got), tt.want)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("DockerDaemonConfig() changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestDockerConfig(t *testing.T) {
+	auths := []rundata.RegistryAuth{
+		{Registry: "harbor.k8s.local", Username: "admin", Password: "secret"},
+		{Registry: "docker.io", Username: "kubei", Password: "secret"},
+	}
+	tests := []struct {
+		name    string
+		current string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "(docker_config) new config file",
+			want: dedent.Dedent(`
+				{
+				  "auths": {
+				    "harbor.k8s.local": {
+				      "auth": "YWRtaW46c2VjcmV0"
+				    },
+				    "https://index.docker.io/v1/": {
+				      "auth": "a3ViZWk6c2VjcmV0"
+				    }
+				  }
+				}
+			`)[1:],
+		},
+		{
+			name: "(docker_config) merged config file keeps the other credentials",
+			current: `{
+				"auths": {
+				  "harbor.k8s.local": {"auth": "b2xkOm9sZA=="},
+				  "quay.io": {"auth": "cXVheTpxdWF5", "email": "ops@example.com"}
+				},
+				"credHelpers": {"123456789.dkr.ecr.cn-north-1.amazonaws.com.cn": "ecr-login"},
+				"HttpHeaders": {"User-Agent": "Docker-Client/19.03"}
+			}`,
+			want: dedent.Dedent(`
+				{
+				  "HttpHeaders": {
+				    "User-Agent": "Docker-Client/19.03"
+				  },
+				  "auths": {
+				    "harbor.k8s.local": {
+				      "auth": "YWRtaW46c2VjcmV0"
+				    },
+				    "https://index.docker.io/v1/": {
+				      "auth": "a3ViZWk6c2VjcmV0"
+				    },
+				    "quay.io": {
+				      "auth": "cXVheTpxdWF5",
+				      "email": "ops@example.com"
+				    }
+				  },
+				  "credHelpers": {
+				    "123456789.dkr.ecr.cn-north-1.amazonaws.com.cn": "ecr-login"
+				  }
+				}
+			`)[1:],
+		},
+		{
+			name:    "(docker_config) invalid config file",
+			current: `{"auths": [}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DockerConfig([]byte(tt.current), auths)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DockerConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("DockerConfig() got = %v, want %v", string(got), tt.want)
 			}
 		})
 	}
@@ -332,7 +510,7 @@ func TestApt_KubeComponent(t *testing.T) {
 				installType: constants.InstallTypeOffline,
 			},
 			want: dedent.Dedent(`
-				sh /tmp/.kubei/kubernetes/default.sh
+				sh /tmp/.kubei/kube/default.sh
 			`),
 		},
 	}
@@ -414,7 +592,7 @@ func TestYum_KubeComponent(t *testing.T) {
 			want: dedent.Dedent(`
 				setenforce 0 || true
 				sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config
-				sh /tmp/.kubei/kubernetes/default.sh
+				sh /tmp/.kubei/kube/default.sh
 			`),
 		},
 	}
@@ -432,3 +610,608 @@ func TestYum_KubeComponent(t *testing.T) {
 		})
 	}
 }
+
+func TestApt_Upgrade(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        func(version, installType string) (string, error)
+		installType string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "(apt_upgrade) online upgrade kubeadm cmd",
+			text:        Apt{}.UpgradeKubeadm,
+			installType: constants.InstallTypeOnline,
+			want: dedent.Dedent(`
+				apt-get update -qq && apt-get install -qq -y apt-transport-https curl
+				curl -s https://mirrors.aliyun.com/kubernetes/apt/doc/apt-key.gpg | apt-key add - >/dev/null
+				cat <<EOF | tee /etc/apt/sources.list.d/kubernetes.list
+				deb https://mirrors.aliyun.com/kubernetes/apt/ kubernetes-xenial main
+				EOF
+				apt-get update -qq
+				KUBE_VER=$(apt-cache madison kubeadm | awk '/1.18.2/ {print$3}' | head -1)
+				apt-get install -qq -y --allow-change-held-packages kubeadm=$KUBE_VER
+				apt-mark hold kubeadm
+			`),
+		},
+		{
+			name:        "(apt_upgrade) offline upgrade kubeadm cmd",
+			text:        Apt{}.UpgradeKubeadm,
+			installType: constants.InstallTypeOffline,
+			want: dedent.Dedent(`
+				dpkg -i --force-depends $(find /tmp/.kubei/kube -name 'kubeadm*.deb' | head -1)
+			`),
+		},
+		{
+			name:        "(apt_upgrade) online upgrade kubelet cmd",
+			text:        Apt{}.UpgradeKubelet,
+			installType: constants.InstallTypeOnline,
+			want: dedent.Dedent(`
+				KUBE_VER=$(apt-cache madison kubelet | awk '/1.18.2/ {print$3}' | head -1)
+				apt-get install -qq -y --allow-change-held-packages kubelet=$KUBE_VER kubectl=$KUBE_VER
+				apt-mark hold kubelet kubectl
+			`),
+		},
+		{
+			name:        "(apt_upgrade) offline upgrade kubelet cmd",
+			text:        Apt{}.UpgradeKubelet,
+			installType: constants.InstallTypeOffline,
+			want: dedent.Dedent(`
+				sh /tmp/.kubei/kube/default.sh
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.text("1.18.2", tt.installType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Upgrade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Upgrade() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYum_Upgrade(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        func(version, installType string) (string, error)
+		installType string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "(yum_upgrade) online upgrade kubeadm cmd",
+			text:        Yum{}.UpgradeKubeadm,
+			installType: constants.InstallTypeOnline,
+			want: dedent.Dedent(`
+				cat <<EOF | tee /etc/yum.repos.d/kubernetes.repo
+				[kubernetes]
+				name=Kubernetes
+				baseurl=https://mirrors.aliyun.com/kubernetes/yum/repos/kubernetes-el7-x86_64
+				enabled=1
+				gpgcheck=1
+				repo_gpgcheck=1
+				gpgkey=https://mirrors.aliyun.com/kubernetes/yum/doc/yum-key.gpg https://mirrors.aliyun.com/kubernetes/yum/doc/rpm-package-key.gpg
+				EOF
+				KUBE_VER=$(yum list kubeadm --showduplicates | awk '/1.18.2/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
+				yum install -y kubeadm-$KUBE_VER --disableexcludes=kubernetes
+			`),
+		},
+		{
+			name:        "(yum_upgrade) offline upgrade kubeadm cmd",
+			text:        Yum{}.UpgradeKubeadm,
+			installType: constants.InstallTypeOffline,
+			want: dedent.Dedent(`
+				rpm -Uvh --replacepkgs --nodeps $(find /tmp/.kubei/kube -name '*kubeadm*.rpm' | head -1)
+			`),
+		},
+		{
+			name:        "(yum_upgrade) online upgrade kubelet cmd",
+			text:        Yum{}.UpgradeKubelet,
+			installType: constants.InstallTypeOnline,
+			want: dedent.Dedent(`
+				KUBE_VER=$(yum list kubelet --showduplicates | awk '/1.18.2/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
+				yum install -y kubelet-$KUBE_VER kubectl-$KUBE_VER --disableexcludes=kubernetes
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.text("1.18.2", tt.installType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Upgrade() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Upgrade() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApt_IPVS(t *testing.T) {
+	tests := []struct {
+		name        string
+		installType string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "(apt_ipvs) online install cmd",
+			installType: constants.InstallTypeOnline,
+			want: dedent.Dedent(`
+				apt-get update -qq && DEBIAN_FRONTEND=noninteractive apt-get install -qq -y ipset ipvsadm
+			`),
+		},
+		{
+			name:        "(apt_ipvs) offline install cmd",
+			installType: constants.InstallTypeOffline,
+			want: dedent.Dedent(`
+				sh /tmp/.kubei/ipvs/default.sh
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := Apt{}
+			got, err := ap.IPVS(tt.installType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IPVS() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("IPVS() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYum_IPVS(t *testing.T) {
+	tests := []struct {
+		name        string
+		installType string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "(yum_ipvs) online install cmd",
+			installType: constants.InstallTypeOnline,
+			want: dedent.Dedent(`
+				yum install -y -q ipset ipvsadm
+			`),
+		},
+		{
+			name:        "(yum_ipvs) offline install cmd",
+			installType: constants.InstallTypeOffline,
+			want: dedent.Dedent(`
+				sh /tmp/.kubei/ipvs/default.sh
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yu := Yum{}
+			got, err := yu.IPVS(tt.installType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IPVS() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("IPVS() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYum_Containerd(t *testing.T) {
+	type args struct {
+		installType string
+		c           rundata.Containerd
+	}
+	config := dedent.Dedent(`
+		cat <<EOF | tee /etc/modules-load.d/containerd.conf
+		overlay
+		br_netfilter
+		EOF
+		modprobe -a overlay br_netfilter
+		cat <<EOF | tee /etc/crictl.yaml
+		runtime-endpoint: unix:///run/containerd/containerd.sock
+		image-endpoint: unix:///run/containerd/containerd.sock
+		EOF
+	`)
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "(yum_containerd) online install cmd",
+			args: args{
+				installType: constants.InstallTypeOnline,
+				c:           rundata.Containerd{Version: "1.3.7", CGroupDriver: "systemd"},
+			},
+			want: dedent.Dedent(`
+				yum install -y -q yum-utils
+				yum-config-manager --add-repo \
+				  https://mirrors.aliyun.com/docker-ce/linux/centos/docker-ce.repo
+				CONTAINERD_VER=$(yum list containerd.io --showduplicates | awk '/1.3.7/ {print$2}' | tail -1 | sed 's/[[:digit:]]://')
+				yum install -y -q containerd.io-$CONTAINERD_VER`) + config,
+		},
+		{
+			name: "(yum_containerd) offline install cmd",
+			args: args{
+				installType: constants.InstallTypeOffline,
+				c:           rundata.Containerd{Version: "1.3.7", CGroupDriver: "systemd"},
+			},
+			want: dedent.Dedent(`
+				sh /tmp/.kubei/container_engine/default.sh`) + config,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yu := Yum{}
+			got, err := yu.Containerd(tt.args.installType, tt.args.c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Containerd() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Containerd() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerdConfig(t *testing.T) {
+	type args struct {
+		c            rundata.Containerd
+		sandboxImage string
+		r            rundata.Registry
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "(containerd) config without registries",
+			args: args{
+				c:            rundata.Containerd{CGroupDriver: "systemd"},
+				sandboxImage: "k8s.gcr.io/pause:3.2",
+				r:            rundata.Registry{Mirrors: []string{}},
+			},
+			want: dedent.Dedent(`
+				version = 2
+				[plugins."io.containerd.grpc.v1.cri"]
+				  sandbox_image = "k8s.gcr.io/pause:3.2"
+				  [plugins."io.containerd.grpc.v1.cri".containerd]
+				    default_runtime_name = "runc"
+				    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+				      runtime_type = "io.containerd.runc.v2"
+				      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+				        SystemdCgroup = true
+			`),
+		},
+		{
+			name: "(containerd) config with mirrors, insecure registries and auths",
+			args: args{
+				c:            rundata.Containerd{CGroupDriver: constants.DefaultCGroupDriver},
+				sandboxImage: "harbor.k8s.local/k8s/pause:3.2",
+				r: rundata.Registry{
+					Mirrors:            strings.Split(constants.DefaultRegistryMirrors, ","),
+					InsecureRegistries: []string{"harbor.k8s.local"},
+					Auths: []rundata.RegistryAuth{
+						{Registry: "harbor.k8s.local", Username: "admin", Password: `pa"ss`},
+						{Registry: "docker.io", Username: "kubei", Password: "secret"},
+					},
+				},
+			},
+			want: dedent.Dedent(`
+				version = 2
+				[plugins."io.containerd.grpc.v1.cri"]
+				  sandbox_image = "harbor.k8s.local/k8s/pause:3.2"
+				  [plugins."io.containerd.grpc.v1.cri".containerd]
+				    default_runtime_name = "runc"
+				    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+				      runtime_type = "io.containerd.runc.v2"
+				      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+				        SystemdCgroup = false
+				  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+				    endpoint = ["https://dockerhub.mirrors.nwafu.edu.cn/", "https://hub-mirror.c.163.com", "https://registry-1.docker.io"]
+				  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."harbor.k8s.local"]
+				    endpoint = ["https://harbor.k8s.local", "http://harbor.k8s.local"]
+				  [plugins."io.containerd.grpc.v1.cri".registry.configs."harbor.k8s.local".tls]
+				    insecure_skip_verify = true
+				  [plugins."io.containerd.grpc.v1.cri".registry.configs."harbor.k8s.local".auth]
+				    username = "admin"
+				    password = "pa\"ss"
+				  [plugins."io.containerd.grpc.v1.cri".registry.configs."registry-1.docker.io".auth]
+				    username = "kubei"
+				    password = "secret"
+			`),
+		},
+		{
+			name: "(containerd) config with duplicate insecure registries and docker.io",
+			args: args{
+				c:            rundata.Containerd{CGroupDriver: "systemd"},
+				sandboxImage: "k8s.gcr.io/pause:3.2",
+				r: rundata.Registry{
+					Mirrors:            []string{"https://hub-mirror.c.163.com"},
+					InsecureRegistries: []string{"harbor.k8s.local", "docker.io", "harbor.k8s.local"},
+					Auths: []rundata.RegistryAuth{
+						{Registry: "harbor.k8s.local", Username: "ädmin", Password: "p\\a\x00ss\tword\x7f\U0001F511"},
+					},
+				},
+			},
+			want: dedent.Dedent(`
+				version = 2
+				[plugins."io.containerd.grpc.v1.cri"]
+				  sandbox_image = "k8s.gcr.io/pause:3.2"
+				  [plugins."io.containerd.grpc.v1.cri".containerd]
+				    default_runtime_name = "runc"
+				    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+				      runtime_type = "io.containerd.runc.v2"
+				      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+				        SystemdCgroup = true
+				  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+				    endpoint = ["https://hub-mirror.c.163.com", "https://registry-1.docker.io", "http://registry-1.docker.io"]
+				  [plugins."io.containerd.grpc.v1.cri".registry.configs."registry-1.docker.io".tls]
+				    insecure_skip_verify = true
+				  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."harbor.k8s.local"]
+				    endpoint = ["https://harbor.k8s.local", "http://harbor.k8s.local"]
+				  [plugins."io.containerd.grpc.v1.cri".registry.configs."harbor.k8s.local".tls]
+				    insecure_skip_verify = true
+				  [plugins."io.containerd.grpc.v1.cri".registry.configs."harbor.k8s.local".auth]
+				    username = "ädmin"
+				    password = "p\\a\u0000ss\tword\u007F🔑"
+			`),
+		},
+		{
+			name: "(containerd) config with docker.io as an insecure registry without mirrors",
+			args: args{
+				c:            rundata.Containerd{CGroupDriver: "systemd"},
+				sandboxImage: "k8s.gcr.io/pause:3.2",
+				r:            rundata.Registry{Mirrors: []string{}, InsecureRegistries: []string{"docker.io"}},
+			},
+			want: dedent.Dedent(`
+				version = 2
+				[plugins."io.containerd.grpc.v1.cri"]
+				  sandbox_image = "k8s.gcr.io/pause:3.2"
+				  [plugins."io.containerd.grpc.v1.cri".containerd]
+				    default_runtime_name = "runc"
+				    [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc]
+				      runtime_type = "io.containerd.runc.v2"
+				      [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+				        SystemdCgroup = true
+				  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+				    endpoint = ["https://registry-1.docker.io", "http://registry-1.docker.io"]
+				  [plugins."io.containerd.grpc.v1.cri".registry.configs."registry-1.docker.io".tls]
+				    insecure_skip_verify = true
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ContainerdConfig(tt.args.c, tt.args.sandboxImage, tt.args.r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ContainerdConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ContainerdConfig() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApt_CRIO(t *testing.T) {
+	type args struct {
+		installType string
+		c           rundata.CRIO
+		pauseImage  string
+		r           rundata.Registry
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "(apt_crio) offline install cmd",
+			args: args{
+				installType: constants.InstallTypeOffline,
+				c:           rundata.CRIO{Version: "1.18", CGroupDriver: constants.DefaultCGroupDriver},
+				pauseImage:  "k8s.gcr.io/pause:3.2",
+				r:           rundata.Registry{Mirrors: []string{}},
+			},
+			want: dedent.Dedent(`
+				sh /tmp/.kubei/container_engine/default.sh
+				cat <<EOF | tee /etc/modules-load.d/crio.conf
+				overlay
+				br_netfilter
+				EOF
+				modprobe -a overlay br_netfilter
+				mkdir -p /etc/crio/crio.conf.d
+				cat <<EOF | tee /etc/crio/crio.conf.d/01-kubei.conf
+				[crio.runtime]
+				cgroup_manager = "cgroupfs"
+				conmon_cgroup = "pod"
+
+				[crio.image]
+				pause_image = "k8s.gcr.io/pause:3.2"
+				registries = [
+				  "docker.io",
+				]
+				EOF
+				cat <<EOF | tee /etc/crictl.yaml
+				runtime-endpoint: unix:///var/run/crio/crio.sock
+				image-endpoint: unix:///var/run/crio/crio.sock
+				EOF
+			`),
+		},
+		{
+			name: "(apt_crio) offline install cmd with registries",
+			args: args{
+				installType: constants.InstallTypeOffline,
+				c:           rundata.CRIO{Version: "1.18", CGroupDriver: "systemd"},
+				pauseImage:  "k8s.gcr.io/pause:3.2",
+				r: rundata.Registry{
+					Mirrors:            []string{"https://dockerhub.mirrors.nwafu.edu.cn/", "http://10.0.0.5:5000"},
+					InsecureRegistries: []string{"harbor.k8s.local"},
+					Auths:              []rundata.RegistryAuth{{Registry: "harbor.k8s.local", Username: "admin", Password: "secret"}},
+				},
+			},
+			want: dedent.Dedent(`
+				sh /tmp/.kubei/container_engine/default.sh
+				cat <<EOF | tee /etc/modules-load.d/crio.conf
+				overlay
+				br_netfilter
+				EOF
+				modprobe -a overlay br_netfilter
+				mkdir -p /etc/crio/crio.conf.d
+				cat <<EOF | tee /etc/crio/crio.conf.d/01-kubei.conf
+				[crio.runtime]
+				cgroup_manager = "systemd"
+				conmon_cgroup = "system.slice"
+
+				[crio.image]
+				pause_image = "k8s.gcr.io/pause:3.2"
+				global_auth_file = "/var/lib/kubelet/config.json"
+				registries = [
+				  "docker.io",
+				]
+				EOF
+				mkdir -p /etc/containers
+				cat <<EOF | tee /etc/containers/registries.conf
+				unqualified-search-registries = ["docker.io"]
+
+				[[registry]]
+				prefix = "docker.io"
+				location = "docker.io"
+
+				[[registry.mirror]]
+				location = "dockerhub.mirrors.nwafu.edu.cn"
+
+				[[registry.mirror]]
+				location = "10.0.0.5:5000"
+				insecure = true
+
+				[[registry]]
+				location = "harbor.k8s.local"
+				insecure = true
+				EOF
+				cat <<EOF | tee /etc/crictl.yaml
+				runtime-endpoint: unix:///var/run/crio/crio.sock
+				image-endpoint: unix:///var/run/crio/crio.sock
+				EOF
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := Apt{}
+			got, err := ap.CRIO(tt.args.installType, tt.args.c, tt.args.pauseImage, tt.args.r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CRIO() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("CRIO() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyEnv(t *testing.T) {
+	proxy := rundata.Proxy{
+		HTTPProxy:  "http://proxy.k8s.local:3128",
+		HTTPSProxy: "http://proxy.k8s.local:3128",
+		AllNoProxy: []string{"localhost", "127.0.0.1", "10.0.0.1", "10.244.0.0/16"},
+	}
+	type args struct {
+		installType string
+		p           rundata.Proxy
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "(proxy) online",
+			args: args{installType: constants.InstallTypeOnline, p: proxy},
+			want: dedent.Dedent(`
+				export http_proxy='http://proxy.k8s.local:3128' HTTP_PROXY='http://proxy.k8s.local:3128'
+				export https_proxy='http://proxy.k8s.local:3128' HTTPS_PROXY='http://proxy.k8s.local:3128'
+				export no_proxy='localhost,127.0.0.1,10.0.0.1,10.244.0.0/16' NO_PROXY='localhost,127.0.0.1,10.0.0.1,10.244.0.0/16'
+			`),
+		},
+		{
+			name: "(proxy) offline",
+			args: args{installType: constants.InstallTypeOffline, p: proxy},
+			want: "",
+		},
+		{
+			name: "(proxy) not set",
+			args: args{installType: constants.InstallTypeOnline},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProxyEnv(tt.args.installType, tt.args.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProxyEnv() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ProxyEnv() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyDropIn(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       rundata.Proxy
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "(proxy) drop-in",
+			p: rundata.Proxy{
+				HTTPProxy:  "http://proxy.k8s.local:3128",
+				HTTPSProxy: "https://proxy.k8s.local:3129",
+				AllNoProxy: []string{"localhost", "127.0.0.1", "10.96.0.0/12"},
+			},
+			want: dedent.Dedent(`
+				[Service]
+				Environment="HTTP_PROXY=http://proxy.k8s.local:3128"
+				Environment="HTTPS_PROXY=https://proxy.k8s.local:3129"
+				Environment="NO_PROXY=localhost,127.0.0.1,10.96.0.0/12"
+			`)[1:],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProxyDropIn(tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProxyDropIn() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ProxyDropIn() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,22 +4,32 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/lithammer/dedent"
+	"github.com/yuyicai/kubei/internal/rundata"
 	"text/template"
 )
 
-func KubeletUnitFile(image string) string {
+// KubeletUnitFile runs the kubelet without a cluster to boot up the local SLB as static Pod. The cgroup driver
+// of Docker is detected, the other container engines are reached through their CRI socket.
+func KubeletUnitFile(image string, engine rundata.ContainerEngine) string {
+	cgroupDriver := `$(docker info --format '{{json .CgroupDriver}}' | sed 's/"//g')`
+	var runtime string
+	if socket := engine.CRISocket(); socket != "" {
+		cgroupDriver = engine.CGroupDriver()
+		runtime = fmt.Sprintf(" --container-runtime=remote --container-runtime-endpoint=unix://%s", socket)
+	}
+
 	cmdTmpl := dedent.Dedent(`
-        cgroupDriver=$(docker info --format '{{json .CgroupDriver}}' | sed 's/"//g')
+        cgroupDriver=%s
         mkdir -p /etc/systemd/system/kubelet.service.d
         cat << EOF | tee /etc/systemd/system/kubelet.service.d/20-ha-service-manager.conf
         [Service]
         ExecStart=
-        ExecStart=/usr/bin/kubelet --address=127.0.0.1 --pod-manifest-path=/etc/kubernetes/manifests --pod-infra-container-image=%s --cgroup-driver=${cgroupDriver}
+        ExecStart=/usr/bin/kubelet --address=127.0.0.1 --pod-manifest-path=/etc/kubernetes/manifests --pod-infra-container-image=%s --cgroup-driver=${cgroupDriver}%s
         Restart=always
         EOF
 	`)
 
-	return fmt.Sprintf(cmdTmpl, image)
+	return fmt.Sprintf(cmdTmpl, cgroupDriver, image, runtime)
 }
 
 func RemoveKubeletUnitFile() string {
@@ -53,7 +63,7 @@ func NginxConf(masters []string, nginxPort, masterPort string) (string, error) {
           upstream kube_apiserver {
             least_conn;
         {{range $master := .masters}}
-            server {{ $master }}:{{ .masterPort }};
+            server {{ $master }}:{{ $.masterPort }};
         {{- end}}
           }
         
@@ -135,3 +145,11 @@ func NginxManifest(nginxImage string) string {
 	`)
 	return fmt.Sprintf(cmdTmpl, nginxImage)
 }
+
+// NginxReload reloads the nginx of the local SLB, through crictl for the container engines other than Docker
+func NginxReload(engine rundata.ContainerEngine) string {
+	if engine.CRISocket() != "" {
+		return "crictl ps -q --name nginx-proxy | xargs -r -I{} crictl exec {} nginx -s reload"
+	}
+	return "docker ps -q -f name=k8s_nginx-proxy | xargs -r -I{} docker exec {} nginx -s reload"
+}
@@ -1,77 +1,32 @@
 package tmpl
 
 import (
-	"bytes"
+	"fmt"
+
 	"github.com/lithammer/dedent"
-	"text/template"
 
+	"github.com/yuyicai/kubei/internal/constants"
 	"github.com/yuyicai/kubei/internal/rundata"
 )
 
-const (
-	Init             = "init"
-	JoinNode         = "joinNode"
-	JoinControlPlane = "joinControlPlane"
-)
-
-func Kubeadm(tmplName, nodeName string, kubernetes rundata.Kubernetes, kubeadmCfg rundata.Kubeadm) (string, error) {
-	token := kubernetes.Token
-	m := map[string]interface{}{
-		"nodeName":             nodeName,
-		"imageRepository":      kubeadmCfg.ImageRepository,
-		"podNetworkCidr":       kubeadmCfg.Networking.PodSubnet,
-		"serviceCidr":          kubeadmCfg.Networking.ServiceSubnet,
-		"controlPlaneEndpoint": kubeadmCfg.ControlPlaneEndpoint,
-		"token":                token.Token,
-		"caCertHash":           token.CaCertHash,
-		"certificateKey":       token.CertificateKey,
-		"version":              kubernetes.Version,
-	}
-
-	t, err := template.New(Init).Parse(dedent.Dedent(`
-        kubeadm init \
-          --kubernetes-version $(kubeadm version -o short) \
-          --image-repository {{ .imageRepository }} \
-          --pod-network-cidr {{ .podNetworkCidr }} \
-          --service-cidr {{ .serviceCidr }} \
-          --upload-certs \
-          --control-plane-endpoint {{ .controlPlaneEndpoint }} \
-          --node-name {{ .nodeName }}
-	`))
-	if err != nil {
-		return "", err
-	}
-
-	_, err = t.New(JoinNode).Parse(dedent.Dedent(`
-        kubeadm join {{ .controlPlaneEndpoint }} --token {{ .token }}  \
-          --discovery-token-ca-cert-hash sha256:{{ .caCertHash }} \
-          --node-name {{ .nodeName }} \
-          --ignore-preflight-errors=DirAvailable--etc-kubernetes-manifests
-	`))
-	if err != nil {
-		return "", err
-	}
-
-	_, err = t.New(JoinControlPlane).Parse(dedent.Dedent(`
-        kubeadm join {{ .controlPlaneEndpoint }} \
-          --token {{ .token }} \
-          --discovery-token-ca-cert-hash sha256:{{ .caCertHash }} \
-          --certificate-key {{ .certificateKey }} \
-          --control-plane \
-          --node-name {{ .nodeName }}
-	`))
-	if err != nil {
-		return "", err
-	}
-
-	var cmdBuff bytes.Buffer
-	err = t.ExecuteTemplate(&cmdBuff, tmplName, m)
-	if err != nil {
-		return "", err
-	}
+// KubeadmInit initializes the first master with the kubeadm configuration file,
+// the file is removed afterwards as it holds the join data
+func KubeadmInit(configFile string) string {
+	cmdTmpl := dedent.Dedent(`
+        trap 'rm -f %[1]s' EXIT
+        kubeadm init --config %[1]s --upload-certs
+	`)
+	return fmt.Sprintf(cmdTmpl, configFile)
+}
 
-	cmd := cmdBuff.String()
-	return cmd, nil
+// KubeadmJoin joins the node with the kubeadm configuration file,
+// the file is removed afterwards as it holds the join data
+func KubeadmJoin(configFile string) string {
+	cmdTmpl := dedent.Dedent(`
+        trap 'rm -f %[1]s' EXIT
+        kubeadm join --config %[1]s
+	`)
+	return fmt.Sprintf(cmdTmpl, configFile)
 }
 
 func CopyAdminConfig() string {
@@ -84,3 +39,58 @@ func CopyAdminConfig() string {
 func ChownKubectlConfig() string {
 	return "chown $SUDO_USER:$SUDO_UID $HOME/.kube/config"
 }
+
+// CreateToken creates the bootstrap token generated by kubei
+func CreateToken(token string) string {
+	return fmt.Sprintf("kubeadm token create %s", token)
+}
+
+func KubeadmVersion() string {
+	return "kubeadm version -o short"
+}
+
+// UploadCerts uploads the certificates encrypted with the certificate key generated by kubei
+func UploadCerts(certificateKey string) string {
+	return fmt.Sprintf("kubeadm init phase upload-certs --upload-certs --certificate-key %s", certificateKey)
+}
+
+// DrainNode drains the node, a node that is not registered, e.g. it is half joined or already deleted, is skipped
+func DrainNode(nodeName string) string {
+	cmdTmpl := dedent.Dedent(`
+        NODE=$(kubectl get node %[1]s -o name --ignore-not-found) || exit 1
+        if [ -n "$NODE" ]; then
+          kubectl drain %[1]s --ignore-daemonsets --delete-local-data --force --timeout 5m
+        fi
+	`)
+	return fmt.Sprintf(cmdTmpl, nodeName)
+}
+
+func DeleteNode(nodeName string) string {
+	return fmt.Sprintf("kubectl delete node %s --ignore-not-found", nodeName)
+}
+
+// RemoveEtcdMember removes the etcd member of the master through the etcd static Pod of another master.
+// The v3 API is set as etcdctl of etcd 3.3 defaults to the v2 API, and it fails if the members can not be listed.
+func RemoveEtcdMember(masterName, nodeName string) string {
+	cmdTmpl := dedent.Dedent(`
+        ETCDCTL="kubectl -n kube-system exec etcd-%[1]s -- env ETCDCTL_API=3 etcdctl --endpoints https://127.0.0.1:2379 \
+          --cacert /etc/kubernetes/pki/etcd/ca.crt \
+          --cert /etc/kubernetes/pki/etcd/server.crt \
+          --key /etc/kubernetes/pki/etcd/server.key"
+        MEMBERS=$($ETCDCTL member list) || exit 1
+        MEMBER_ID=$(echo "$MEMBERS" | awk -F', ' '$3 == "%[2]s" {print $1}')
+        if [ -n "$MEMBER_ID" ]; then
+          $ETCDCTL member remove $MEMBER_ID
+        fi
+	`)
+	return fmt.Sprintf(cmdTmpl, masterName, nodeName)
+}
+
+// LoadOfflineImages loads the images of the offline package for the master or the node.
+// The images of containerd are the image archives in /tmp/.kubei/images/<master|node>/
+func LoadOfflineImages(nodeType string, engine rundata.ContainerEngine) string {
+	if engine.Type == constants.ContainerEngineTypeContainerd {
+		return fmt.Sprintf(`for image in /tmp/.kubei/images/%s/*.tar; do ctr -n k8s.io images import "$image" || exit 1; done`, nodeType)
+	}
+	return fmt.Sprintf("sh /tmp/.kubei/images/%s.sh", nodeType)
+}
@@ -0,0 +1,62 @@
+package tmpl
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/lithammer/dedent"
+
+	"github.com/yuyicai/kubei/internal/constants"
+	"github.com/yuyicai/kubei/internal/rundata"
+)
+
+// ProxyEnv exports the proxy to the online installation scripts, apt, yum and curl read it from the environment.
+// It is empty for the offline installation or if the proxy is not set.
+func ProxyEnv(installType string, p rundata.Proxy) (string, error) {
+	if installType != constants.InstallTypeOnline || !p.IsSet() {
+		return "", nil
+	}
+	return executeProxy(dedent.Dedent(`
+		{{- if .httpProxy }}
+		export http_proxy='{{ .httpProxy }}' HTTP_PROXY='{{ .httpProxy }}'
+		{{- end }}
+		{{- if .httpsProxy }}
+		export https_proxy='{{ .httpsProxy }}' HTTPS_PROXY='{{ .httpsProxy }}'
+		{{- end }}
+		export no_proxy='{{ .noProxy }}' NO_PROXY='{{ .noProxy }}'
+	`), p)
+}
+
+// ProxyDropIn renders the systemd drop-in that sets the proxy of a container engine, so it pulls the images through the proxy
+func ProxyDropIn(p rundata.Proxy) (string, error) {
+	return executeProxy(dedent.Dedent(`
+		[Service]
+		{{- if .httpProxy }}
+		Environment="HTTP_PROXY={{ .httpProxy }}"
+		{{- end }}
+		{{- if .httpsProxy }}
+		Environment="HTTPS_PROXY={{ .httpsProxy }}"
+		{{- end }}
+		Environment="NO_PROXY={{ .noProxy }}"
+	`)[1:], p)
+}
+
+func executeProxy(text string, p rundata.Proxy) (string, error) {
+	m := map[string]interface{}{
+		"httpProxy":  p.HTTPProxy,
+		"httpsProxy": p.HTTPSProxy,
+		"noProxy":    strings.Join(p.AllNoProxy, ","),
+	}
+	t, err := template.New("text").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buff bytes.Buffer
+	if err := t.Execute(&buff, m); err != nil {
+		return "", err
+	}
+
+	return buff.String(), nil
+}
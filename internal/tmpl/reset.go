@@ -6,3 +6,8 @@ func ResetHosts(apiDomainName string) string {
 	cmdTmpl := "sed -i '/%s/d' /etc/hosts"
 	return fmt.Sprintf(cmdTmpl, apiDomainName)
 }
+
+// ResetIPVS clears the IPVS tables left by kube-proxy, "kubeadm reset" does not clean them
+func ResetIPVS() string {
+	return "if command -v ipvsadm >/dev/null 2>&1; then ipvsadm --clear; fi"
+}
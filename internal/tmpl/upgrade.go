@@ -0,0 +1,30 @@
+package tmpl
+
+import (
+	"fmt"
+)
+
+func ServerVersion() string {
+	return "kubectl version --short | awk '/Server Version/ {print $3}'"
+}
+
+// KubeletVersions prints the name and the kubelet version of each node, one node per line
+func KubeletVersions() string {
+	return `kubectl get nodes -o jsonpath='{range .items[*]}{.metadata.name} {.status.nodeInfo.kubeletVersion}{"\n"}{end}'`
+}
+
+func UpgradePlan(version string) string {
+	return fmt.Sprintf("kubeadm upgrade plan v%s", version)
+}
+
+func UpgradeApply(version string) string {
+	return fmt.Sprintf("kubeadm upgrade apply -y v%s", version)
+}
+
+func UpgradeNode() string {
+	return "kubeadm upgrade node"
+}
+
+func UncordonNode(nodeName string) string {
+	return fmt.Sprintf("kubectl uncordon %s", nodeName)
+}
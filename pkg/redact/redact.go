@@ -0,0 +1,82 @@
+// Package redact masks the secrets known by kubei, so the logs and the errors can be shared safely.
+package redact
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the secrets.
+const Mask = "******"
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+
+	// pemBlock matches the PEM blocks: the private keys, and the certificates that are masked too to keep the logs short
+	pemBlock = regexp.MustCompile(`-----BEGIN ([A-Z0-9 ]+)-----[\s\S]*?-----END ([A-Z0-9 ]+)-----`)
+)
+
+// Add registers the secrets, the empty ones are ignored.
+func Add(s ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, secret := range s {
+		if secret == "" || contains(secrets, secret) {
+			continue
+		}
+		secrets = append(secrets, secret)
+	}
+	// the longer secrets are masked first, so a secret containing another one is fully masked
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// String masks the registered secrets and the PEM blocks of s.
+func String(s string) string {
+	s = pemBlock.ReplaceAllString(s, "-----BEGIN $1-----\n"+Mask+"\n-----END $2-----")
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, Mask, -1)
+	}
+	return s
+}
+
+// Error returns an error with the message of err masked, it is nil if err is nil.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.New(String(err.Error()))
+}
+
+// Writer masks the data before it is written to w, each write must hold whole lines.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func contains(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
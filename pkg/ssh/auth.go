@@ -0,0 +1,208 @@
+package ssh
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/terminal"
+	"k8s.io/klog"
+
+	"github.com/yuyicai/kubei/pkg/redact"
+)
+
+const (
+	// AuthMethodAgent authenticates with the keys held by the ssh-agent listening on SSH_AUTH_SOCK.
+	AuthMethodAgent = "agent"
+	// AuthMethodKey authenticates with a private key file, which may be protected by a passphrase.
+	AuthMethodKey = "key"
+	// AuthMethodPassword authenticates with a password.
+	AuthMethodPassword = "password"
+	// AuthMethodKeyboardInteractive answers the password prompts of the server.
+	AuthMethodKeyboardInteractive = "keyboard-interactive"
+)
+
+// DefaultAuthMethods is the order in which the authentication methods are tried when none is selected.
+var DefaultAuthMethods = []string{AuthMethodAgent, AuthMethodKey, AuthMethodPassword, AuthMethodKeyboardInteractive}
+
+// Config holds the settings used to authenticate to a host and to verify its host key.
+type Config struct {
+	User     string
+	Password string
+	Key      string
+	// Passphrase decrypts Key, the user is prompted for it when the key is encrypted and Passphrase is empty.
+	Passphrase string
+	// AuthMethods are tried in order. When it is empty, DefaultAuthMethods are tried,
+	// skipping the methods that have no credentials.
+	AuthMethods     []string
+	HostKeyCallback ssh.HostKeyCallback
+}
+
+var (
+	agentOnce   sync.Once
+	agentClient agent.Agent
+	agentErr    error
+
+	// signers caches the parsed private keys, so the passphrase of a key is asked only once
+	signersMu sync.Mutex
+	signers   = map[string]ssh.Signer{}
+
+	// promptMu serializes the interactive prompts of the connections that are set up in parallel
+	promptMu sync.Mutex
+)
+
+func authMethods(cfg *Config) ([]ssh.AuthMethod, error) {
+	explicit := len(cfg.AuthMethods) > 0
+	methods := cfg.AuthMethods
+	if !explicit {
+		methods = DefaultAuthMethods
+	}
+
+	var auth []ssh.AuthMethod
+	for _, method := range methods {
+		switch method {
+		case AuthMethodAgent:
+			a, err := sshAgent()
+			if err != nil {
+				if explicit {
+					return nil, err
+				}
+				klog.V(5).Infof("[ssh] Skip the ssh-agent authentication: %v", err)
+				continue
+			}
+			auth = append(auth, ssh.PublicKeysCallback(a.Signers))
+		case AuthMethodKey:
+			if cfg.Key == "" {
+				if explicit {
+					return nil, errors.New("the key authentication is selected, but no key is set")
+				}
+				continue
+			}
+			signer, err := makePrivateKeySignerFromFile(cfg.Key, cfg.Passphrase)
+			if err != nil {
+				return nil, err
+			}
+			auth = append(auth, ssh.PublicKeys(signer))
+		case AuthMethodPassword:
+			if cfg.Password == "" {
+				if explicit {
+					return nil, errors.New("the password authentication is selected, but no password is set")
+				}
+				continue
+			}
+			auth = append(auth, ssh.Password(cfg.Password))
+		case AuthMethodKeyboardInteractive:
+			// without a password, only ask the user when the method is selected explicitly
+			if cfg.Password == "" && !explicit {
+				continue
+			}
+			auth = append(auth, ssh.KeyboardInteractive(keyboardInteractive(cfg.Password)))
+		default:
+			return nil, errors.Errorf("unsupported SSH authentication method: %s, supported methods: %s",
+				method, strings.Join(DefaultAuthMethods, ", "))
+		}
+	}
+
+	if len(auth) == 0 {
+		return nil, errors.Errorf("no SSH authentication method is available for user %s, set a key, a password or SSH_AUTH_SOCK", cfg.User)
+	}
+	return auth, nil
+}
+
+// sshAgent connects to the ssh-agent once, the connection is shared by all the SSH connections.
+func sshAgent() (agent.Agent, error) {
+	agentOnce.Do(func() {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			agentErr = errors.New("SSH_AUTH_SOCK is not set")
+			return
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			agentErr = errors.Wrapf(err, "unable to connect to ssh-agent %s", socket)
+			return
+		}
+		agentClient = agent.NewClient(conn)
+	})
+	return agentClient, agentErr
+}
+
+func makePrivateKeySignerFromFile(key, passphrase string) (ssh.Signer, error) {
+	signersMu.Lock()
+	defer signersMu.Unlock()
+
+	if signer, ok := signers[key]; ok {
+		return signer, nil
+	}
+
+	buffer, err := ioutil.ReadFile(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key %s: %v", key, err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(buffer)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		if passphrase == "" {
+			passphrase, err = prompt(fmt.Sprintf("Enter passphrase for key '%s': ", key))
+			if err != nil {
+				return nil, fmt.Errorf("unable to read the passphrase of private key %s: %v", key, err)
+			}
+			redact.Add(passphrase)
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key %s: %v", key, err)
+	}
+
+	signers[key] = signer
+	return signer, nil
+}
+
+// keyboardInteractive answers the questions that do not echo with the password,
+// or asks the user when no password is set.
+func keyboardInteractive(password string) ssh.KeyboardInteractiveChallenge {
+	return func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i, question := range questions {
+			if password != "" && !echos[i] {
+				answers[i] = password
+				continue
+			}
+			answer, err := prompt(fmt.Sprintf("(%s) %s", user, question))
+			if err != nil {
+				return nil, err
+			}
+			if !echos[i] {
+				redact.Add(answer)
+			}
+			answers[i] = answer
+		}
+		return answers, nil
+	}
+}
+
+// prompt reads a secret from the terminal without echoing it.
+func prompt(message string) (string, error) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", errors.New("stdin is not a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, message)
+	b, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
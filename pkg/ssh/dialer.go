@@ -0,0 +1,106 @@
+package ssh
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+)
+
+// Hop is a host on the way to the target host, or the target host itself.
+type Hop struct {
+	Host   string
+	Port   string
+	Config *Config
+}
+
+func (h Hop) address() string {
+	return h.Config.User + "@" + net.JoinHostPort(h.Host, h.Port)
+}
+
+// Dialer connects to hosts through chains of jump servers.
+// The connections to the jump servers are shared by all the hosts behind them, and are closed by Close.
+type Dialer struct {
+	mu      sync.Mutex
+	clients map[string]*Client
+	// order is the order in which the jump servers were connected
+	order []string
+}
+
+func NewDialer() *Dialer {
+	return &Dialer{clients: map[string]*Client{}}
+}
+
+// Connect connects to the last hop through the hops before it, in order.
+// The returned client is owned by the caller.
+func (d *Dialer) Connect(hops []Hop) (*Client, error) {
+	if len(hops) == 0 {
+		return nil, errors.New("no host to connect to")
+	}
+
+	jumpServer, err := d.jumpServer(hops[:len(hops)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	target := hops[len(hops)-1]
+	if jumpServer == nil {
+		return Connect(target.Host, target.Port, target.Config)
+	}
+	return ConnectByJumpServer(target.Host, target.Port, target.Config, jumpServer)
+}
+
+// jumpServer returns the client of the last jump server, connecting to the jump servers that are not connected yet.
+func (d *Dialer) jumpServer(hops []Hop) (*Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var client *Client
+	addresses := make([]string, 0, len(hops))
+	for _, hop := range hops {
+		addresses = append(addresses, hop.address())
+		key := strings.Join(addresses, ",")
+		if c, ok := d.clients[key]; ok {
+			client = c
+			continue
+		}
+
+		var err error
+		klog.V(5).Infof("[ssh] Connecting to jump server %s", key)
+		if client == nil {
+			client, err = Connect(hop.Host, hop.Port, hop.Config)
+		} else {
+			client, err = ConnectByJumpServer(hop.Host, hop.Port, hop.Config, client)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to connect to jump server %s", hop.Host)
+		}
+		d.clients[key] = client
+		d.order = append(d.order, key)
+	}
+	return client, nil
+}
+
+// Close closes the connections to the jump servers, the farthest first.
+func (d *Dialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errs []string
+	for i := len(d.order) - 1; i >= 0; i-- {
+		key := d.order[i]
+		klog.V(5).Infof("[ssh] Closing jump server connection %s", key)
+		if err := d.clients[key].Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		delete(d.clients, key)
+	}
+	d.order = nil
+
+	if len(errs) > 0 {
+		return errors.Errorf("unable to close jump server connections: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"k8s.io/klog"
+)
+
+const (
+	// HostKeyPolicyStrict only accepts host keys that are already recorded in the known_hosts file.
+	HostKeyPolicyStrict = "strict"
+	// HostKeyPolicyAcceptNew records the keys of unknown hosts in the known_hosts file (trust on first use),
+	// but still rejects hosts whose key has changed.
+	HostKeyPolicyAcceptNew = "accept-new"
+	// HostKeyPolicyInsecure accepts every host key without verification.
+	HostKeyPolicyInsecure = "insecure"
+)
+
+// DefaultKnownHostsFile returns the known_hosts file of the current user.
+func DefaultKnownHostsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// NewHostKeyCallback returns a HostKeyCallback that verifies host keys with the given policy.
+func NewHostKeyCallback(policy, knownHostsFile string) (ssh.HostKeyCallback, error) {
+	switch policy {
+	case HostKeyPolicyInsecure:
+		klog.Warning("[ssh] Host key verification is disabled, the connections are vulnerable to man-in-the-middle attacks")
+		return ssh.InsecureIgnoreHostKey(), nil
+	case HostKeyPolicyStrict:
+		if knownHostsFile == "" {
+			return nil, errors.New("no known_hosts file is specified")
+		}
+		callback, err := knownhosts.New(knownHostsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read known_hosts file %s", knownHostsFile)
+		}
+		return callback, nil
+	case HostKeyPolicyAcceptNew:
+		if knownHostsFile == "" {
+			return nil, errors.New("no known_hosts file is specified")
+		}
+		if err := touchKnownHostsFile(knownHostsFile); err != nil {
+			return nil, err
+		}
+		callback, err := knownhosts.New(knownHostsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read known_hosts file %s", knownHostsFile)
+		}
+		r := &knownHostsRecorder{
+			file:     knownHostsFile,
+			callback: callback,
+			added:    map[string]ssh.PublicKey{},
+		}
+		return r.check, nil
+	default:
+		return nil, errors.Errorf("unsupported host key policy: %s, supported policy: %s, %s, %s",
+			policy, HostKeyPolicyStrict, HostKeyPolicyAcceptNew, HostKeyPolicyInsecure)
+	}
+}
+
+// knownHostsRecorder verifies host keys with a known_hosts file and appends the keys of unknown hosts to it.
+type knownHostsRecorder struct {
+	mu       sync.Mutex
+	file     string
+	callback ssh.HostKeyCallback
+	// added holds the keys recorded by this recorder, the callback only knows the keys read at creation time
+	added map[string]ssh.PublicKey
+}
+
+func (r *knownHostsRecorder) check(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.callback(hostname, remote, key)
+	keyErr, ok := err.(*knownhosts.KeyError)
+	if !ok || len(keyErr.Want) > 0 {
+		// known host with a matching key, a changed key, a revoked key or an unexpected error
+		return err
+	}
+
+	address := knownhosts.Normalize(hostname)
+	if k, ok := r.added[address]; ok {
+		if bytes.Equal(k.Marshal(), key.Marshal()) {
+			return nil
+		}
+		return errors.Errorf("host key for %s has changed since it was added to %s", address, r.file)
+	}
+
+	if err := appendKnownHost(r.file, knownhosts.Line([]string{address}, key)); err != nil {
+		return err
+	}
+	r.added[address] = key
+	klog.Warningf("[ssh] Permanently added %s (%s) to the list of known hosts %s", address, key.Type(), r.file)
+	return nil
+}
+
+func touchKnownHostsFile(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return errors.Wrapf(err, "unable to create the directory of known_hosts file %s", file)
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create known_hosts file %s", file)
+	}
+	return f.Close()
+}
+
+func appendKnownHost(file, line string) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read known_hosts file %s", file)
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open known_hosts file %s", file)
+	}
+	defer f.Close()
+
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		line = "\n" + line
+	}
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		return errors.Wrapf(err, "unable to write known_hosts file %s", file)
+	}
+	return nil
+}
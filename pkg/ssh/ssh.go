@@ -6,15 +6,16 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/pkg/sftp"
+	"github.com/yuyicai/kubei/pkg/redact"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/errgroup"
 	"io"
-	"io/ioutil"
 	"k8s.io/klog"
 	"net"
 	"os"
 	"path"
 	"strings"
+	"time"
 )
 
 type Client struct {
@@ -24,8 +25,8 @@ type Client struct {
 	user     string
 }
 
-func Connect(host, port, user, password, key string) (*Client, error) {
-	config, err := setConf(user, password, key)
+func Connect(host, port string, cfg *Config) (*Client, error) {
+	config, err := setConf(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -34,11 +35,11 @@ func Connect(host, port, user, password, key string) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Client{client: client, host: host, password: password, user: user}, nil
+	return &Client{client: client, host: host, password: cfg.Password, user: cfg.User}, nil
 }
 
-func ConnectByJumpServer(host, port, user, password, key string, jumpServer *Client) (*Client, error) {
-	config, err := setConf(user, password, key)
+func ConnectByJumpServer(host, port string, cfg *Config, jumpServer *Client) (*Client, error) {
+	config, err := setConf(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -52,40 +53,27 @@ func ConnectByJumpServer(host, port, user, password, key string, jumpServer *Cli
 		return nil, err
 	}
 
-	return &Client{client: ssh.NewClient(ncc, chans, reqs), host: host, password: password, user: user}, nil
+	return &Client{client: ssh.NewClient(ncc, chans, reqs), host: host, password: cfg.Password, user: cfg.User}, nil
 }
 
-func setConf(user, password, key string) (*ssh.ClientConfig, error) {
-	config := &ssh.ClientConfig{
-		User:            user,
-		HostKeyCallback: ssh.HostKeyCallback(func(hostname string, remote net.Addr, key ssh.PublicKey) error { return nil }),
-	}
-
-	if key != "" {
-		signer, err := makePrivateKeySignerFromFile(key)
-		if err != nil {
-			return nil, err
-		}
-		config.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
-	} else {
-		config.Auth = []ssh.AuthMethod{ssh.Password(password)}
-	}
-	return config, nil
-}
-
-func makePrivateKeySignerFromFile(key string) (ssh.Signer, error) {
+func setConf(cfg *Config) (*ssh.ClientConfig, error) {
+	// the password is also the sudo password sent to the host
+	redact.Add(cfg.Password, cfg.Passphrase)
 
-	buffer, err := ioutil.ReadFile(key)
-	if err != nil {
-		return nil, fmt.Errorf("unable to read private key %s: %v", key, err)
+	if cfg.HostKeyCallback == nil {
+		return nil, errors.New("no host key callback is set")
 	}
 
-	signer, err := ssh.ParsePrivateKey(buffer)
+	auth, err := authMethods(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse private key: %v", err)
+		return nil, err
 	}
 
-	return signer, nil
+	return &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            auth,
+		HostKeyCallback: cfg.HostKeyCallback,
+	}, nil
 }
 
 func (c *Client) Close() error {
@@ -238,6 +226,60 @@ func (c *Client) SendFile(dstFile, srcFile string) error {
 	return nil
 }
 
+// SendData writes the data to the remote file with the mode, the file is owned by root.
+// The data is streamed through SFTP, so it is neither on a command line nor logged.
+// A user who is not root writes the data to a temporary file in its home directory first,
+// then the file is installed with sudo.
+func (c *Client) SendData(dstFile string, data []byte, mode os.FileMode) error {
+	sc, err := sftp.NewClient(c.client)
+	if err != nil {
+		return fmt.Errorf("unable to start sftp subsytem: %v", err)
+	}
+	defer sc.Close()
+
+	if c.user == "root" {
+		if err := sc.MkdirAll(path.Dir(dstFile)); err != nil {
+			return err
+		}
+		if err := writeFile(sc, dstFile, data, mode); err != nil {
+			return err
+		}
+		return sc.Chown(dstFile, 0, 0)
+	}
+
+	home, err := sc.Getwd()
+	if err != nil {
+		return err
+	}
+	tmpFile := path.Join(home, fmt.Sprintf(".kubei-%d-%s", time.Now().UnixNano(), path.Base(dstFile)))
+	if err := writeFile(sc, tmpFile, data, 0600); err != nil {
+		_ = sc.Remove(tmpFile)
+		return err
+	}
+
+	klog.V(6).Infof("[%s] [sftp] Install %s to %s with mode %04o", c.host, tmpFile, dstFile, mode)
+	if err := c.Run(fmt.Sprintf("install -D -m %04o -o root -g root %[2]s %[3]s\nrm -f %[2]s", mode, tmpFile, dstFile)); err != nil {
+		_ = sc.Remove(tmpFile)
+		return err
+	}
+	return nil
+}
+
+// writeFile truncates the file and restricts its mode before the data is written
+func writeFile(sc *sftp.Client, file string, data []byte, mode os.FileMode) error {
+	w, err := sc.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if err := sc.Chmod(file, mode); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
 func sendSudoPassword(password, host string, in io.WriteCloser, out io.Reader) error {
 	var line string
 
@@ -0,0 +1,185 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kevinburke/ssh_config"
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+)
+
+// maxProxyJumpDepth limits the nesting of ProxyJump settings, to stop on loops in the ssh_config file.
+const maxProxyJumpDepth = 10
+
+// HostConfig holds the settings of a host that are resolved from the ssh_config file.
+// The fields that are not set in the file are empty.
+type HostConfig struct {
+	// Alias is the name used to refer to the host, e.g. on the command line or in ProxyJump
+	Alias        string
+	HostName     string
+	User         string
+	Port         string
+	IdentityFile string
+}
+
+// SSHConfig resolves hosts with an OpenSSH client configuration file, see ssh_config(5).
+// The Host, HostName, User, Port, IdentityFile and ProxyJump keywords are supported.
+type SSHConfig struct {
+	cfg *ssh_config.Config
+}
+
+// DefaultSSHConfigFile returns the ssh_config file of the current user.
+func DefaultSSHConfigFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "config")
+}
+
+// LoadSSHConfig reads the ssh_config file, a missing file is treated as an empty file.
+func LoadSSHConfig(file string) (*SSHConfig, error) {
+	c := &SSHConfig{}
+	if file == "" {
+		return c, nil
+	}
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		klog.V(5).Infof("[ssh] The ssh_config file %s does not exist", file)
+		return c, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open ssh_config file %s", file)
+	}
+	defer f.Close()
+
+	c.cfg, err = ssh_config.Decode(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse ssh_config file %s", file)
+	}
+	return c, nil
+}
+
+// Resolve returns the settings of the host referred to by spec, which has the form "[user@]host[:port]".
+// The user and port in spec take precedence over the ssh_config file.
+func (c *SSHConfig) Resolve(spec string) (HostConfig, error) {
+	user, alias, port, err := parseHostSpec(spec)
+	if err != nil {
+		return HostConfig{}, err
+	}
+
+	hc := HostConfig{Alias: alias, HostName: alias, User: user, Port: port}
+	if v := c.get(alias, "HostName"); v != "" {
+		hc.HostName = strings.Replace(v, "%h", alias, -1)
+	}
+	if hc.User == "" {
+		hc.User = c.get(alias, "User")
+	}
+	if hc.Port == "" {
+		hc.Port = c.get(alias, "Port")
+	}
+	if v := c.get(alias, "IdentityFile"); v != "" {
+		hc.IdentityFile = expandHome(v)
+	}
+	return hc, nil
+}
+
+// ProxyJump returns the jump servers to reach the host through, in connecting order,
+// according to the ProxyJump settings of the host and of its jump servers.
+func (c *SSHConfig) ProxyJump(alias string) ([]HostConfig, error) {
+	return c.proxyJump(c.get(alias, "ProxyJump"), 0)
+}
+
+// ParseProxyJump resolves a ProxyJump value "[user@]host[:port],..." to the jump servers in connecting order.
+func (c *SSHConfig) ParseProxyJump(value string) ([]HostConfig, error) {
+	return c.proxyJump(value, 0)
+}
+
+func (c *SSHConfig) proxyJump(value string, depth int) ([]HostConfig, error) {
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+	if depth > maxProxyJumpDepth {
+		return nil, errors.Errorf("too many levels of ProxyJump at %q", value)
+	}
+
+	var hops []HostConfig
+	for i, spec := range strings.Split(value, ",") {
+		hc, err := c.Resolve(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, err
+		}
+
+		// like OpenSSH, only the first jump server is reached with its own ProxyJump setting,
+		// the others are reached through the jump servers before them
+		if i == 0 {
+			parents, err := c.proxyJump(c.get(hc.Alias, "ProxyJump"), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			hops = append(hops, parents...)
+		}
+		hops = append(hops, hc)
+	}
+	return hops, nil
+}
+
+func (c *SSHConfig) get(alias, key string) (value string) {
+	if c.cfg == nil {
+		return ""
+	}
+
+	// ssh_config panics on the Match keyword, which is not supported
+	defer func() {
+		if r := recover(); r != nil {
+			klog.Warningf("[ssh] Unable to get %s of %s from the ssh_config file: %v", key, alias, r)
+			value = ""
+		}
+	}()
+
+	value, err := c.cfg.Get(alias, key)
+	if err != nil {
+		klog.Warningf("[ssh] Unable to get %s of %s from the ssh_config file: %v", key, alias, err)
+		return ""
+	}
+	return value
+}
+
+// parseHostSpec parses "[user@]host[:port]", IPv6 addresses with a port must be enclosed in square brackets.
+func parseHostSpec(spec string) (user, host, port string, err error) {
+	host = spec
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		user, host = host[:i], host[i+1:]
+	}
+
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		// an IPv6 address in square brackets without a port
+		host = host[1 : len(host)-1]
+	} else if strings.HasPrefix(host, "[") || strings.Count(host, ":") == 1 {
+		host, port, err = net.SplitHostPort(host)
+		if err != nil {
+			return "", "", "", fmt.Errorf("invalid host %q: %v", spec, err)
+		}
+	}
+
+	if host == "" {
+		return "", "", "", fmt.Errorf("invalid host %q: the host is empty", spec)
+	}
+	return user, host, port, nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,187 @@
+package ssh
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHostSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		wantUser string
+		wantHost string
+		wantPort string
+		wantErr  bool
+	}{
+		{name: "host", spec: "10.3.0.10", wantHost: "10.3.0.10"},
+		{name: "user, host and port", spec: "ops@bastion.example.com:2222", wantUser: "ops", wantHost: "bastion.example.com", wantPort: "2222"},
+		{name: "user with @", spec: "ops@corp@10.3.0.10", wantUser: "ops@corp", wantHost: "10.3.0.10"},
+		{name: "IPv6 without brackets", spec: "ops@fe80::1", wantUser: "ops", wantHost: "fe80::1"},
+		{name: "IPv6 in brackets", spec: "[fe80::1]", wantHost: "fe80::1"},
+		{name: "IPv6 in brackets with port", spec: "ops@[fe80::1]:2222", wantUser: "ops", wantHost: "fe80::1", wantPort: "2222"},
+		{name: "unclosed bracket", spec: "[fe80::1:2222", wantErr: true},
+		{name: "empty host", spec: "ops@", wantErr: true},
+		{name: "empty host with port", spec: ":2222", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, host, port, err := parseHostSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseHostSpec() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if user != tt.wantUser || host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("parseHostSpec() got = %q, %q, %q, want %q, %q, %q", user, host, port, tt.wantUser, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+const testSSHConfig = `
+Host bastion
+  HostName 192.168.10.10
+  User ops
+  Port 2222
+  ProxyJump gateway
+
+Host gateway
+  HostName 10.0.0.1
+
+Host second
+  HostName 192.168.20.10
+  ProxyJump unused
+
+Host node
+  ProxyJump bastion,second
+
+Host direct
+  ProxyJump none
+
+Host loop-a
+  ProxyJump loop-b
+
+Host loop-b
+  ProxyJump loop-a
+`
+
+func loadTestSSHConfig(t *testing.T) *SSHConfig {
+	dir, err := ioutil.TempDir("", "kubei-ssh-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config")
+	if err := ioutil.WriteFile(file, []byte(testSSHConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadSSHConfig(file)
+	if err != nil {
+		t.Fatalf("LoadSSHConfig() error = %v", err)
+	}
+	return c
+}
+
+func TestProxyJump(t *testing.T) {
+	gateway := HostConfig{Alias: "gateway", HostName: "10.0.0.1"}
+	bastion := HostConfig{Alias: "bastion", HostName: "192.168.10.10", User: "ops", Port: "2222"}
+	second := HostConfig{Alias: "second", HostName: "192.168.20.10"}
+
+	tests := []struct {
+		name    string
+		alias   string
+		want    []HostConfig
+		wantErr string
+	}{
+		{
+			name:  "no ProxyJump",
+			alias: "10.3.0.10",
+		},
+		{
+			name:  "ProxyJump none",
+			alias: "direct",
+		},
+		{
+			name:  "ProxyJump of a jump server",
+			alias: "bastion",
+			want:  []HostConfig{gateway},
+		},
+		{
+			name:  "only the first jump server of a chain is reached with its own ProxyJump",
+			alias: "node",
+			want:  []HostConfig{gateway, bastion, second},
+		},
+		{
+			name:    "loop",
+			alias:   "loop-a",
+			wantErr: "too many levels of ProxyJump",
+		},
+	}
+	c := loadTestSSHConfig(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ProxyJump(tt.alias)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ProxyJump() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProxyJump() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProxyJump() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProxyJump(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []HostConfig
+		wantErr bool
+	}{
+		{
+			name:  "user and port of the value take precedence",
+			value: "root@bastion:22",
+			want: []HostConfig{
+				{Alias: "gateway", HostName: "10.0.0.1"},
+				{Alias: "bastion", HostName: "192.168.10.10", User: "root", Port: "22"},
+			},
+		},
+		{
+			name:  "chain starting with an IPv6 address",
+			value: "ops@[fe80::1]:2222, bastion",
+			want: []HostConfig{
+				{Alias: "fe80::1", HostName: "fe80::1", User: "ops", Port: "2222"},
+				{Alias: "bastion", HostName: "192.168.10.10", User: "ops", Port: "2222"},
+			},
+		},
+		{
+			name:    "invalid host",
+			value:   "bastion,ops@",
+			wantErr: true,
+		},
+	}
+	c := loadTestSSHConfig(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ParseProxyJump(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseProxyJump() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseProxyJump() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}